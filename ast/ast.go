@@ -78,6 +78,30 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// A const statement looks exactly like a let statement, 'const <name> = <expression>', but the binding
+// it creates can never be assigned to or redeclared in the same scope.
+type ConstStatement struct {
+	Token token.Token // the token.CONST token
+	Name  *Variable
+	Value Expression
+}
+
+func (cs *ConstStatement) statementNode()       {}
+func (cs *ConstStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ConstStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(cs.TokenLiteral() + " ")
+	out.WriteString(cs.Name.String())
+	out.WriteString(" = ")
+
+	if cs.Value != nil {
+		out.WriteString(cs.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
 // Any return statement has just 2 parts (return <expression>) the return keyword and an expression.
 type ReturnStatement struct {
 	Token       token.Token // the return token
@@ -114,6 +138,84 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+//FunctionStatement declares a named function, 'func <name>(<parameters>) <block statement>'.
+//Unlike a FunctionLiteral bound with let, these declarations are hoisted to the top of the enclosing block,
+//so functions declared in the same block can call each other regardless of the order they are written in.
+type FunctionStatement struct {
+	Token    token.Token // The 'func' token
+	Name     *Variable
+	Function *FunctionLiteral
+}
+
+func (fs *FunctionStatement) statementNode()       {}
+func (fs *FunctionStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *FunctionStatement) String() string       { return fs.Function.String() }
+
+//AssignStatement rebinds a variable that was already declared with let, '<target> = <expression>'.
+//The binding is updated in the scope that declared it, which is how closures share mutable state.
+//The target can also be an index expression, '<array or hash>[<index>] = <expression>', which changes the collection in place.
+type AssignStatement struct {
+	Token  token.Token // the '=' token
+	Target Expression
+	Value  Expression
+}
+
+func (as *AssignStatement) statementNode()       {}
+func (as *AssignStatement) TokenLiteral() string { return as.Token.Literal }
+func (as *AssignStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(as.Target.String())
+	out.WriteString(" = ")
+	if as.Value != nil {
+		out.WriteString(as.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+//WhileStatement evaluates its body for as long as the condition is truthy, 'while (<condition>) <block statement>'.
+type WhileStatement struct {
+	Token     token.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+	return out.String()
+}
+
+//ForStatement evaluates its body once for every element of an iterable, 'for (<variable> in <expression>) <block statement>'.
+//Every iteration gets a fresh binding of the variable, so closures created in the body each see their own element.
+type ForStatement struct {
+	Token    token.Token // the 'for' token
+	Variable *Variable
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for(")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+	return out.String()
+}
+
 // The token field what every statement has and the actual expression field that holds the entire expression.
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
@@ -130,9 +232,14 @@ func (es *ExpressionStatement) String() string {
 }
 
 // This is to hold the variable in the let statement. This implements the expression interface so it's an expression Node.
+// Local, Depth and Index are filled in by the resolver. For a variable declared in a function, block or loop
+// the value lives in slot Index of the environment Depth scopes out, every other variable is looked up by name.
 type Variable struct {
 	Token token.Token // the token.VARIABLE token
 	Value string
+	Local bool
+	Depth int
+	Index int
 }
 
 func (v *Variable) expressionNode()      {}
@@ -149,6 +256,16 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+//FloatLiteral holds a number with a fractional part like 1.5, this is an expression as well.
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 //StringLiteral represents the string in the language. These are expressions as they evaluate to strings.
 type StringLiteral struct {
 	Token token.Token
@@ -240,6 +357,7 @@ func (ie *IfExpression) String() string {
 //Functions are firstclass citizens here which means these can be used as expression so shouldn't be a surprise when functionLiteral implements the expressionNode
 type FunctionLiteral struct {
 	Token      token.Token // The 'func' token
+	Name       string      // Set only when the literal belongs to a FunctionStatement
 	Parameters []*Variable
 	Body       *BlockStatement
 }
@@ -255,6 +373,9 @@ func (fl *FunctionLiteral) String() string {
 	}
 
 	out.WriteString(fl.TokenLiteral())
+	if fl.Name != "" {
+		out.WriteString(" " + fl.Name)
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ","))
 	out.WriteString(")")
@@ -309,6 +430,22 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+//SetLiteral holds the sets in the language, '#{1, 2, 3}'.
+type SetLiteral struct {
+	Token    token.Token // the '#{' token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode()      {}
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) String() string {
+	elements := []string{}
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+	return "#{" + strings.Join(elements, ", ") + "}"
+}
+
 type IndexExpression struct {
 	Token token.Token // the [ token
 	Left  Expression
@@ -328,10 +465,63 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+//SliceExpression is 'left[start:end:step]', any of the three can be left out and are nil then.
+type SliceExpression struct {
+	Token token.Token // the [ token
+	Left  Expression
+	Start Expression
+	End   Expression
+	Step  Expression
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("])")
+	return out.String()
+}
+
+//MemberExpression is 'object.property', the property of a hash or a method of a builtin type.
+//Property is just a name, it doesn't refer to any variable.
+type MemberExpression struct {
+	Token    token.Token // the . token
+	Object   Expression
+	Property *Variable
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	return "(" + me.Object.String() + "." + me.Property.String() + ")"
+}
+
+//HashLiteralPair is one 'key: value' entry of a HashLiteral.
+type HashLiteralPair struct {
+	Key   Expression
+	Value Expression
+}
+
 //HashLiteral holds the maps in the language. this implements the expression interface so itself it's an expression.
+//Pairs are kept in the order they are written in, which is also the order they are evaluated in.
 type HashLiteral struct {
 	Token token.Token // the '{' token
-	Pairs map[Expression]Expression
+	Pairs []HashLiteralPair
 }
 
 func (hl *HashLiteral) expressionNode()      {}
@@ -340,8 +530,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -2,10 +2,84 @@ package evaluator
 
 import (
 	"fmt"
+	"sort"
+	"unicode/utf8"
 
 	"github.com/Neeraj-Natu/shifu/object"
 )
 
+//BuiltinNames lists the names of all builtin functions and constants, sorted.
+//The resolver needs them to tell a call to a builtin apart from a typo.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins)+len(constants))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range constants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//freeze marks arrays and hashes as frozen, along with every array and hash nested in them.
+//Anything else is immutable already and is returned untouched. Frozen values are skipped, which also ends cycles.
+func freeze(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Array:
+		if obj.Frozen {
+			return obj
+		}
+		obj.Frozen = true
+		for _, el := range obj.Elements {
+			freeze(el)
+		}
+	case *object.Hash:
+		if obj.Frozen {
+			return obj
+		}
+		obj.Frozen = true
+		for _, pair := range obj.Pairs() {
+			freeze(pair.Key)
+			freeze(pair.Value)
+		}
+	}
+	return obj
+}
+
+//ordinals name the position of an argument in error messages, the first one goes without.
+var ordinals = []string{"", "second ", "third ", "fourth ", "fifth "}
+
+//argumentError reports an argument of the wrong type the same way for every builtin,
+//position counts from 0 and expected is what the argument should have been such as "an ARRAY".
+func argumentError(name string, position int, expected string, got object.Object) *object.Error {
+	ordinal := fmt.Sprintf("argument %d ", position+1)
+	if position < len(ordinals) {
+		ordinal = ordinals[position]
+	}
+	return newError("%sargument to '%s' must be %s, got %s", ordinal, name, expected, got.Type())
+}
+
+//wrongNumberOfArguments is the error for a builtin that takes from min to max arguments.
+func wrongNumberOfArguments(got, min, max int) *object.Error {
+	if min == max {
+		return newError("wrong number of arguments. got=%d, expected=%d", got, min)
+	}
+	if max == min+1 {
+		return newError("wrong number of arguments. got=%d, expected=%d or %d", got, min, max)
+	}
+	return newError("wrong number of arguments. got=%d, expected=%d to %d", got, min, max)
+}
+
+//hashKeyArgument returns the argument as a hash key, or the error telling it can't be one.
+func hashKeyArgument(arg object.Object) (object.Hashable, *object.Error) {
+	key, ok := object.AsHashable(arg)
+	if !ok {
+		return nil, newError("unusable as hash key: %s", arg.Type())
+	}
+	return key, nil
+}
+
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
@@ -16,6 +90,12 @@ var builtins = map[string]*object.Builtin{
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.String:
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
+			case *object.Set:
+				return &object.Integer{Value: int64(arg.Len())}
+			case *object.Bytes:
 				return &object.Integer{Value: int64(len(arg.Value))}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
@@ -28,7 +108,7 @@ var builtins = map[string]*object.Builtin{
 				return newError("wrong number of arguments. got=%d, expected=1", len(args))
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to 'first' must be an ARRAY, got %s", args[0].Type())
+				return argumentError("first", 0, "an ARRAY", args[0])
 			}
 			arr := args[0].(*object.Array)
 			if len(arr.Elements) > 0 {
@@ -43,7 +123,7 @@ var builtins = map[string]*object.Builtin{
 				return newError("wrong number of arguments. got=%d, expected=1", len(args))
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to 'last' must be an ARRAY, got %s", args[0].Type())
+				return argumentError("last", 0, "an ARRAY", args[0])
 			}
 			arr := args[0].(*object.Array)
 			length := len(arr.Elements)
@@ -53,52 +133,201 @@ var builtins = map[string]*object.Builtin{
 			return newError("an array has no elements!")
 		},
 	},
+	//push and pop return new arrays and leave the one they're given as it is, see object.Array for how that is kept cheap.
 	"push": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
 				return newError("wrong number of arguments. got=%d, expected=2", len(args))
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to 'push' must be an ARRAY, got %s", args[0].Type())
+				return argumentError("push", 0, "an ARRAY", args[0])
 			}
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			newElements := make([]object.Object, length+1, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
-
-			return &object.Array{Elements: newElements}
+			return args[0].(*object.Array).Push(args[1])
 		},
 	},
+	//pop takes off the last element, or the one at the given index. Negative indexes count from the end.
 	"pop": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
-			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, expected=1", len(args))
+			if len(args) != 1 && len(args) != 2 {
+				return wrongNumberOfArguments(len(args), 1, 2)
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
-				return newError("argument to 'pop' must be an ARRAY, got %s", args[0].Type())
-			}
-			if args[1].Type() != object.INTEGER_OBJ {
-				return newError("second argument to 'pop' must be an INTEGER, got %s", args[1].Type())
+				return argumentError("pop", 0, "an ARRAY", args[0])
 			}
 			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			idx := args[1].(*object.Integer)
-			index := idx.Value
-			if index < 0 || index >= int64(length) {
+			index := int64(-1)
+			if len(args) == 2 {
+				if args[1].Type() != object.INTEGER_OBJ {
+					return argumentError("pop", 1, "an INTEGER", args[1])
+				}
+				index = args[1].(*object.Integer).Value
+			}
+			i, ok := normalizeIndex(index, len(arr.Elements))
+			if !ok {
 				return newError("Index to pop from is out of bounds!")
 			}
-			newElements := make([]object.Object, length-1, length-1)
-			newElements = append(arr.Elements[:index], arr.Elements[index+1:]...)
-			return &object.Array{Elements: newElements}
+			return arr.Pop(i)
+		},
+	},
+	"keys": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, expected=1", len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return argumentError("keys", 0, "a HASH", args[0])
+			}
+			keys := make([]object.Object, 0, hash.Len())
+			for _, pair := range hash.Pairs() {
+				keys = append(keys, pair.Key)
+			}
+			return &object.Array{Elements: keys}
+		},
+	},
+	"values": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, expected=1", len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return argumentError("values", 0, "a HASH", args[0])
+			}
+			values := make([]object.Object, 0, hash.Len())
+			for _, pair := range hash.Pairs() {
+				values = append(values, pair.Value)
+			}
+			return &object.Array{Elements: values}
+		},
+	},
+	"has": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, expected=2", len(args))
+			}
+			switch collection := args[0].(type) {
+			case *object.Hash:
+				key, err := hashKeyArgument(args[1])
+				if err != nil {
+					return err
+				}
+				_, ok := collection.Get(key)
+				return nativeBoolToBooleanObject(ok)
+			case *object.Set:
+				el, ok := object.AsHashable(args[1])
+				if !ok {
+					return newError("unusable as set element: %s", args[1].Type())
+				}
+				return nativeBoolToBooleanObject(collection.Has(el))
+			default:
+				return argumentError("has", 0, "a HASH or a SET", args[0])
+			}
+		},
+	},
+	//delete leaves the hash it's given as it is and returns a new one without the key, just like push does for arrays.
+	"delete": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, expected=2", len(args))
+			}
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return argumentError("delete", 0, "a HASH", args[0])
+			}
+			key, err := hashKeyArgument(args[1])
+			if err != nil {
+				return err
+			}
+			result := object.NewHash()
+			for _, pair := range hash.Pairs() {
+				if !object.Equals(pair.Key, key) {
+					result.Set(pair.Key.(object.Hashable), pair.Value)
+				}
+			}
+			return result
+		},
+	},
+	//merge returns a new hash with the pairs of all the hashes it's given, for a key that is in more than one of them
+	//the value of the last one wins but the key stays where it was first seen.
+	"merge": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments. got=%d, expected at least 1", len(args))
+			}
+			result := object.NewHash()
+			for i, arg := range args {
+				hash, ok := arg.(*object.Hash)
+				if !ok {
+					return argumentError("merge", i, "a HASH", arg)
+				}
+				for _, pair := range hash.Pairs() {
+					result.Set(pair.Key.(object.Hashable), pair.Value)
+				}
+			}
+			return result
 		},
 	},
-	"puts": &object.Builtin{
+	//slice returns the elements from start up to but not including end, end defaults to the length of the array.
+	//Strings are sliced by characters.
+	"slice": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, expected=2 or 3", len(args))
+			}
+			var length int
+			var runes []rune
+			switch arg := args[0].(type) {
+			case *object.Array:
+				length = len(arg.Elements)
+			case *object.String:
+				runes = []rune(arg.Value)
+				length = len(runes)
+			default:
+				return argumentError("slice", 0, "an ARRAY or a STRING", args[0])
+			}
+			start, ok := args[1].(*object.Integer)
+			if !ok {
+				return argumentError("slice", 1, "an INTEGER", args[1])
+			}
+			end := int64(length)
+			if len(args) == 3 {
+				endArg, ok := args[2].(*object.Integer)
+				if !ok {
+					return argumentError("slice", 2, "an INTEGER", args[2])
+				}
+				end = endArg.Value
+			}
+			if start.Value < 0 || end > int64(length) || start.Value > end {
+				return newError("slice bounds out of range: [%d:%d] with length %d", start.Value, end, length)
+			}
+			if args[0].Type() == object.STRING_OBJ {
+				return &object.String{Value: string(runes[start.Value:end])}
+			}
+			elements := make([]object.Object, end-start.Value)
+			copy(elements, args[0].(*object.Array).Elements[start.Value:end])
+			return &object.Array{Elements: elements}
+		},
+	},
+	"concat": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			elements := []object.Object{}
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return argumentError("concat", i, "an ARRAY", arg)
+				}
+				elements = append(elements, arr.Elements...)
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+	"freeze": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, expected=1", len(args))
 			}
-			return NULL
+			return freeze(args[0])
 		},
 	},
 }
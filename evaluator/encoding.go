@@ -0,0 +1,277 @@
+package evaluator
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//digests are the hash functions 'hmac' can be given by name, each also is a builtin of its own.
+var digests = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+//The builtins to move between strings and bytes and to encode and hash them. Everything taking bytes
+//takes a string as well, it stands for its UTF-8 encoding.
+func init() {
+	builtins["bytes"] = &object.Builtin{Fn: bytesBuiltin}
+	builtins["base64_encode"] = encoder("base64_encode", base64.StdEncoding.EncodeToString)
+	builtins["base64_decode"] = decoder("base64_decode", base64.StdEncoding.DecodeString)
+	builtins["base64url_encode"] = encoder("base64url_encode", base64.RawURLEncoding.EncodeToString)
+	builtins["base64url_decode"] = decoder("base64url_decode", func(s string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	})
+	builtins["hex_encode"] = encoder("hex_encode", hex.EncodeToString)
+	builtins["hex_decode"] = decoder("hex_decode", hex.DecodeString)
+	builtins["url_encode"] = &object.Builtin{Fn: urlEncodeBuiltin}
+	builtins["url_decode"] = &object.Builtin{Fn: urlDecodeBuiltin}
+	builtins["query_encode"] = &object.Builtin{Fn: queryEncodeBuiltin}
+	builtins["query_decode"] = &object.Builtin{Fn: queryDecodeBuiltin}
+
+	for name, newHash := range digests {
+		builtins[name] = digestBuiltin(name, newHash)
+	}
+	builtins["crc32"] = &object.Builtin{Fn: crc32Builtin}
+	builtins["hmac"] = &object.Builtin{Fn: hmacBuiltin}
+}
+
+//bytesArgument returns the bytes of a BYTES or STRING argument.
+func bytesArgument(name string, position int, arg object.Object) ([]byte, *object.Error) {
+	switch arg := arg.(type) {
+	case *object.Bytes:
+		return arg.Value, nil
+	case *object.String:
+		return []byte(arg.Value), nil
+	default:
+		return nil, argumentError(name, position, "BYTES or a STRING", arg)
+	}
+}
+
+//bytes makes bytes of the UTF-8 encoding of a string, or of an array of integers from 0 to 255.
+func bytesBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Bytes:
+		return arg
+	case *object.String:
+		return &object.Bytes{Value: []byte(arg.Value)}
+	case *object.Array:
+		value := make([]byte, len(arg.Elements))
+		for i, el := range arg.Elements {
+			b, ok := el.(*object.Integer)
+			if !ok || b.Value < 0 || b.Value > 255 {
+				return newError("cannot convert %s to a byte", repr(el))
+			}
+			value[i] = byte(b.Value)
+		}
+		return &object.Bytes{Value: value}
+	default:
+		return argumentError("bytes", 0, "a STRING or an ARRAY", arg)
+	}
+}
+
+//bytesToString is how 'str' reads bytes, they have to be valid UTF-8 to be a string.
+func bytesToString(b *object.Bytes) object.Object {
+	if !utf8.Valid(b.Value) {
+		return newError("cannot convert BYTES that aren't valid UTF-8 to STRING")
+	}
+	return &object.String{Value: string(b.Value)}
+}
+
+func encoder(name string, encode func([]byte) string) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongNumberOfArguments(len(args), 1, 1)
+		}
+		value, err := bytesArgument(name, 0, args[0])
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: encode(value)}
+	}}
+}
+
+func decoder(name string, decode func(string) ([]byte, error)) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		values, err := stringArguments(name, args, 1, 1)
+		if err != nil {
+			return err
+		}
+		value, decodeErr := decode(values[0])
+		if decodeErr != nil {
+			return newError("%s: %s", name, decodeErr)
+		}
+		return &object.Bytes{Value: value}
+	}}
+}
+
+//url_encode escapes a string to be put in a URL query, url_decode undoes it.
+func urlEncodeBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("url_encode", args, 1, 1)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: url.QueryEscape(values[0])}
+}
+
+func urlDecodeBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("url_decode", args, 1, 1)
+	if err != nil {
+		return err
+	}
+	value, decodeErr := url.QueryUnescape(values[0])
+	if decodeErr != nil {
+		return newError("url_decode: %s", decodeErr)
+	}
+	return &object.String{Value: value}
+}
+
+//query_encode writes a hash as a URL query like 'a=1&b=2', in the order of the hash.
+//A value that is an array gives the key once for every element.
+func queryEncodeBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	query, ok := args[0].(*object.Hash)
+	if !ok {
+		return argumentError("query_encode", 0, "a HASH", args[0])
+	}
+
+	parts := []string{}
+	for _, pair := range query.Pairs() {
+		key := url.QueryEscape(queryValue(pair.Key))
+		values := []object.Object{pair.Value}
+		if arr, ok := pair.Value.(*object.Array); ok {
+			values = arr.Elements
+		}
+		for _, value := range values {
+			if !isQueryValue(value) {
+				return newError("cannot put %s in a URL query", value.Type())
+			}
+			parts = append(parts, key+"="+url.QueryEscape(queryValue(value)))
+		}
+	}
+	return &object.String{Value: strings.Join(parts, "&")}
+}
+
+func isQueryValue(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.String, *object.Integer, *object.Float, *object.Boolean:
+		return true
+	}
+	return false
+}
+
+func queryValue(obj object.Object) string {
+	if str, ok := obj.(*object.String); ok {
+		return str.Value
+	}
+	return obj.Inspect()
+}
+
+//query_decode reads a URL query into a hash of strings, in the order the keys first appear.
+//A key given more than once has an array of all its values.
+func queryDecodeBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("query_decode", args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{}
+	found := map[string][]object.Object{}
+	for _, part := range strings.Split(values[0], "&") {
+		if part == "" {
+			continue
+		}
+		rawKey, rawValue, _ := strings.Cut(part, "=")
+		key, keyErr := url.QueryUnescape(rawKey)
+		value, valueErr := url.QueryUnescape(rawValue)
+		if keyErr != nil || valueErr != nil {
+			return newError("query_decode: invalid escape in %s", repr(&object.String{Value: part}))
+		}
+		if _, ok := found[key]; !ok {
+			keys = append(keys, key)
+		}
+		found[key] = append(found[key], &object.String{Value: value})
+	}
+
+	query := object.NewHash()
+	for _, key := range keys {
+		if len(found[key]) == 1 {
+			query.Set(&object.String{Value: key}, found[key][0])
+		} else {
+			query.Set(&object.String{Value: key}, &object.Array{Elements: found[key]})
+		}
+	}
+	return query
+}
+
+//digestBuiltin makes the builtin hashing its argument with newHash, the digest is given as bytes.
+func digestBuiltin(name string, newHash func() hash.Hash) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongNumberOfArguments(len(args), 1, 1)
+		}
+		value, err := bytesArgument(name, 0, args[0])
+		if err != nil {
+			return err
+		}
+		h := newHash()
+		h.Write(value)
+		return &object.Bytes{Value: h.Sum(nil)}
+	}}
+}
+
+//crc32 is the IEEE checksum of the argument as an integer.
+func crc32Builtin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	value, err := bytesArgument("crc32", 0, args[0])
+	if err != nil {
+		return err
+	}
+	return &object.Integer{Value: int64(crc32.ChecksumIEEE(value))}
+}
+
+//hmac signs the message with the key using the named digest, like hmac("sha256", key, message).
+func hmacBuiltin(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return wrongNumberOfArguments(len(args), 3, 3)
+	}
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return argumentError("hmac", 0, "a STRING", args[0])
+	}
+	newHash, ok := digests[name.Value]
+	if !ok {
+		return newError("unknown digest for 'hmac': %s, expected md5, sha1, sha256 or sha512", repr(name))
+	}
+	key, err := bytesArgument("hmac", 1, args[1])
+	if err != nil {
+		return err
+	}
+	message, err := bytesArgument("hmac", 2, args[2])
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write(message)
+	return &object.Bytes{Value: mac.Sum(nil)}
+}
@@ -2,6 +2,7 @@ package evaluator
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Neeraj-Natu/shifu/ast"
 	"github.com/Neeraj-Natu/shifu/object"
@@ -24,6 +25,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return Eval(node.Expression, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.PrefixExpression:
@@ -43,11 +46,17 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalInfixExpression(node.Operator, left, right)
 	case *ast.BlockStatement:
-		return evalBlockStatements(node, env)
+		return evalBlockStatements(node, env, false)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return evalIfExpression(node, env, false)
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
+		//A call being returned is always the last thing its function does, so it is a tail call.
+		var val object.Object
+		if call, ok := node.ReturnValue.(*ast.CallExpression); ok {
+			val = evalCallExpression(call, env, true)
+		} else {
+			val = Eval(node.ReturnValue, env)
+		}
 		if isError(val) {
 			return val
 		}
@@ -57,23 +66,40 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if err := checkRedeclaration(node.Name, env); err != nil {
+			return err
+		}
+		bind(node.Name, val, env)
+	case *ast.ConstStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if err := checkRedeclaration(node.Name, env); err != nil {
+			return err
+		}
+		if node.Name.Local {
+			env.SetAt(node.Name.Index, val)
+		} else {
+			env.SetConst(node.Name.Value, val)
+		}
+	case *ast.AssignStatement:
+		return evalAssignStatement(node, env)
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
 	case *ast.Variable:
 		return evalVariable(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return &object.Function{Name: node.Name, Parameters: params, Env: env, Body: body}
+	case *ast.FunctionStatement:
+		//Already bound by hoistFunctions when the enclosing block started evaluating.
+		return nil
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-		return applyFunction(function, args)
+		return evalCallExpression(node, env, false)
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.ArrayLiteral:
@@ -92,8 +118,18 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return index
 		}
 		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
+	case *ast.MemberExpression:
+		obj := Eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return evalMemberExpression(obj, node.Property.Value, env)
 	}
 	return nil
 }
@@ -106,10 +142,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 
+	hoistFunctions(program.Statements, env)
+
 	for _, statement := range program.Statements {
 		result = Eval(statement, env)
 		switch result := result.(type) {
 		case *object.ReturnValue:
+			if tc, ok := result.Value.(*tailCall); ok {
+				return applyFunction(tc.fn, tc.args)
+			}
 			return result.Value
 		case *object.Error:
 			return result
@@ -123,10 +164,16 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 //outerloops in the block statement and only let the most outer loop decide (where result is still nil)
 //which is the first occurence of the ReturnValue object for that loop and only return that.
 //Same for Errors. To stop the errors bubbling up far away in these cases we already have isError function.
-func evalBlockStatements(block *ast.BlockStatement, env *object.Environment) object.Object {
+//When the block is in tail position its last statement is evaluated with evalTailStatement.
+func evalBlockStatements(block *ast.BlockStatement, env *object.Environment, tail bool) object.Object {
 	var result object.Object
 
-	for _, statement := range block.Statements {
+	hoistFunctions(block.Statements, env)
+
+	for i, statement := range block.Statements {
+		if tail && i == len(block.Statements)-1 {
+			return evalTailStatement(statement, env)
+		}
 		result = Eval(statement, env)
 
 		if result != nil {
@@ -139,6 +186,17 @@ func evalBlockStatements(block *ast.BlockStatement, env *object.Environment) obj
 	return result
 }
 
+//Named function declarations are bound before any other statement of their block is evaluated.
+//All of them share the same environment, so functions declared side by side can call each other
+//no matter which one is written first, which is what makes mutual recursion possible.
+func hoistFunctions(statements []ast.Statement, env *object.Environment) {
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			bind(fs.Name, Eval(fs.Function, env), env)
+		}
+	}
+}
+
 // This function's purpose is to convert the native bool to our Boolean Object
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	if input {
@@ -173,28 +231,43 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	case *object.Duration:
+		return &object.Duration{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
-//This function evaluates the infix Expressions it uses pointer comparision for most checks apart from Integer
-//As while evaluating Integers we assign new Objects and hence new memory addresses to them
-//Comparing different memory addresses won't work thus they need their own helper function to compare the
-//values extracted from them.
+//This function evaluates the infix Expressions, Integers and Strings have their own helper functions.
+//== and != on anything else compare the values with object.Equals, so arrays and hashes are compared by
+//what they hold instead of by their memory addresses.
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
+	case operator == "in":
+		return evalInExpression(left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
+	case left.Type() == object.SET_OBJ && right.Type() == object.SET_OBJ:
+		return evalSetInfixExpression(operator, left, right)
+	case operator == "*" && left.Type() == object.STRING_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalStringRepetition(left.(*object.String), right.(*object.Integer))
+	case operator == "*" && left.Type() == object.INTEGER_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringRepetition(right.(*object.String), left.(*object.Integer))
+	case isTimeArithmetic(operator, left, right):
+		return evalTimeInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equals(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equals(left, right))
 	case operator == "&&":
 		return nativeBoolToBooleanObject(left == TRUE && right == TRUE)
 	case operator == "||":
@@ -206,6 +279,45 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	}
 }
 
+//evalFloatInfixExpression works out float arithmetic, an integer on either side is turned into a float first.
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	//equality is left to object.Equals, turning a big integer into a float could make it equal to a float it isn't
+	case "==":
+		return nativeBoolToBooleanObject(object.Equals(left, right))
+	case "!=":
+		return nativeBoolToBooleanObject(!object.Equals(left, right))
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
 func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
@@ -218,6 +330,9 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "*":
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / %d", leftVal, rightVal)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
@@ -232,36 +347,274 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	}
 }
 
+//Strings are compared by their bytes, which for UTF-8 is the same as comparing them by code points.
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
 
-	if operator != "+" {
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
+}
 
-	leftVal := left.(*object.String).Value
-	rightVal := right.(*object.String).Value
+//A string times an integer, in either order, is the string repeated that many times.
+//maxStringLength is the most bytes repeating or padding a string can make, a bigger count is an error
+//instead of taking all the memory of the host.
+const maxStringLength = 1 << 30
+
+func evalStringRepetition(str *object.String, count *object.Integer) object.Object {
+	if count.Value < 0 {
+		return newError("negative repeat count: %d", count.Value)
+	}
+	//dividing keeps the check itself from overflowing
+	if count.Value > 0 && int64(len(str.Value)) > maxStringLength/count.Value {
+		return newError("string too long: repeating %d bytes %d times", len(str.Value), count.Value)
+	}
+	return &object.String{Value: strings.Repeat(str.Value, int(count.Value))}
+}
 
-	return &object.String{Value: leftVal + rightVal}
+//'x in collection' tells if an array or a set holds an element equal to x, if a hash has the key x
+//or if a string contains x as a substring.
+func evalInExpression(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Array:
+		for _, el := range right.Elements {
+			if object.Equals(left, el) {
+				return TRUE
+			}
+		}
+		return FALSE
+	case *object.Hash:
+		key, ok := object.AsHashable(left)
+		if !ok {
+			return newError("unusable as hash key: %s", left.Type())
+		}
+		_, ok = right.Get(key)
+		return nativeBoolToBooleanObject(ok)
+	case *object.Set:
+		el, ok := object.AsHashable(left)
+		if !ok {
+			return newError("unusable as set element: %s", left.Type())
+		}
+		return nativeBoolToBooleanObject(right.Has(el))
+	case *object.String:
+		if left.Type() != object.STRING_OBJ {
+			return newError("unknown operator: %s in %s", left.Type(), right.Type())
+		}
+		return nativeBoolToBooleanObject(strings.Contains(right.Value, left.(*object.String).Value))
+	default:
+		return newError("unknown operator: %s in %s", left.Type(), right.Type())
+	}
 }
 
 //Here we decided the if condition should evaluate to TRUE anytime when the condition is not false or null.
 //Instead of being explicity TRUE. Also incase the condition doesn't evaluate to a value it's supposed to return NULL.
 //These are language design decisions governed by 'isTruthy' function.
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+//Each branch is a block with its own scope, so variables declared in it with let don't leak out of the if.
+//In tail position the value of the if is the value of the function, so the chosen branch is in tail position too.
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment, tail bool) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
 		return condition
 	}
 
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return evalBlockStatements(ie.Consequence, object.NewEnclosedEnvironment(env), tail)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return evalBlockStatements(ie.Alternative, object.NewEnclosedEnvironment(env), tail)
 	} else {
 		return NULL
 	}
 }
 
+//A constant can be shadowed in an inner scope but never declared again in its own scope.
+//For variables in slots the resolver has checked this already.
+func checkRedeclaration(v *ast.Variable, env *object.Environment) *object.Error {
+	if !v.Local && env.IsDeclared(v.Value) && env.IsConst(v.Value) {
+		return newError("cannot redeclare constant %s", v.Value)
+	}
+	return nil
+}
+
+//Declares the variable in the given environment, in the slot the resolver picked for it or else by name.
+func bind(v *ast.Variable, val object.Object, env *object.Environment) {
+	if v.Local {
+		env.SetAt(v.Index, val)
+	} else {
+		env.Set(v.Value, val)
+	}
+}
+
+//Assignment changes the existing binding wherever it was declared instead of creating a new one,
+//so a closure assigning to a variable of its enclosing function changes it for everyone that captured it.
+func evalAssignStatement(node *ast.AssignStatement, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Variable:
+		if target.Local {
+			if _, ok := env.AssignAt(target.Depth, target.Index, val); !ok {
+				return newError("cannot assign to undeclared variable: %s", target.Value)
+			}
+			return nil
+		}
+		if env.IsConst(target.Value) {
+			return newError("cannot assign to constant %s", target.Value)
+		}
+		if _, declared := env.Get(target.Value); !declared && constants[target.Value] != nil {
+			return newError("cannot assign to constant %s", target.Value)
+		}
+		if _, ok := env.Assign(target.Value, val); !ok {
+			return newError("cannot assign to undeclared variable: %s", target.Value)
+		}
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		if result := evalIndexAssignment(left, index, val); isError(result) {
+			return result
+		}
+	case *ast.MemberExpression:
+		obj := Eval(target.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		if obj.Type() != object.HASH_OBJ {
+			return newError("cannot assign to member of %s", obj.Type())
+		}
+		if result := evalIndexAssignment(obj, &object.String{Value: target.Property.Value}, val); isError(result) {
+			return result
+		}
+	}
+	return nil
+}
+
+//Index assignment changes the array or hash in place, every reference to it sees the new value.
+func evalIndexAssignment(left, index, val object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		arrayObject := left.(*object.Array)
+		if arrayObject.Frozen {
+			return newError("cannot modify frozen %s", left.Type())
+		}
+		idx := index.(*object.Integer).Value
+		i, ok := normalizeIndex(idx, len(arrayObject.Elements))
+		if !ok {
+			return newError("Array Index out of bounds: [%d]", idx)
+		}
+		arrayObject.SetElement(i, val)
+		return val
+	case left.Type() == object.HASH_OBJ:
+		hashObject := left.(*object.Hash)
+		if hashObject.Frozen {
+			return newError("cannot modify frozen %s", left.Type())
+		}
+		key, ok := object.AsHashable(index)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		hashObject.Set(key, val)
+		return val
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
+//The body of a while loop is a block, a fresh scope is created for it on every iteration.
+//A return or an error inside the body stops the loop and bubbles up, otherwise the loop evaluates to NULL.
+func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		result := evalBlockStatements(node.Body, object.NewEnclosedEnvironment(env), false)
+		if isReturnOrError(result) {
+			return result
+		}
+	}
+}
+
+//Every iteration of a for loop gets its own scope holding the loop variable, the body is evaluated in it.
+//Closures created in the body therefore capture the element of their own iteration and not the last one.
+func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	elements, err := iterate(iterable)
+	if err != nil {
+		return err
+	}
+
+	for _, element := range elements {
+		iterationEnv := object.NewEnclosedEnvironment(env)
+		bind(node.Variable, element, iterationEnv)
+
+		result := evalBlockStatements(node.Body, iterationEnv, false)
+		if isReturnOrError(result) {
+			return result
+		}
+	}
+	return NULL
+}
+
+//Lists the values a for loop walks over: the elements of an array, the characters of a string or the keys of a hash.
+func iterate(iterable object.Object) ([]object.Object, *object.Error) {
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		return iterable.Elements, nil
+	case *object.String:
+		elements := []object.Object{}
+		for _, ch := range iterable.Value {
+			elements = append(elements, &object.String{Value: string(ch)})
+		}
+		return elements, nil
+	case *object.Hash:
+		elements := []object.Object{}
+		for _, pair := range iterable.Pairs() {
+			elements = append(elements, pair.Key)
+		}
+		return elements, nil
+	case *object.Set:
+		return iterable.Elements(), nil
+	default:
+		return nil, newError("cannot iterate over %s", iterable.Type())
+	}
+}
+
+func isReturnOrError(obj object.Object) bool {
+	if obj != nil {
+		rt := obj.Type()
+		return rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ
+	}
+	return false
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -276,14 +629,24 @@ func isTruthy(obj object.Object) bool {
 }
 
 // function to evaluate Variables in the current Environment, also call Memory in some languages.
+// Variables the resolver gave a slot are read straight from it, the rest are looked up by name.
 func evalVariable(node *ast.Variable, env *object.Environment) object.Object {
+	if node.Local {
+		if val, ok := env.GetAt(node.Depth, node.Index); ok {
+			return val
+		}
+		return newError("variable not found: %s", node.Value)
+	}
 	if val, ok := env.Get(node.Value); ok {
 		return val
 	}
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
-	return newError("variable not found: " + node.Value)
+	if constant, ok := constants[node.Value]; ok {
+		return constant
+	}
+	return newError("variable not found: %s", node.Value)
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
@@ -299,17 +662,77 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
+//tailCall is what a call in tail position evaluates to. Instead of recursing into applyFunction
+//(and so into Eval, growing the Go stack) the callee and its already evaluated arguments are handed back
+//to the applyFunction loop further up, which then runs the call in place of the function that just finished.
+//It never escapes the evaluator: applyFunction and evalProgram always resolve it.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return "tail call" }
+
+func evalCallExpression(node *ast.CallExpression, env *object.Environment, tail bool) object.Object {
+	function := Eval(node.Function, env)
+	if isError(function) {
+		return function
+	}
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	if tail {
+		return &tailCall{fn: function, args: args}
+	}
+	return applyFunction(function, args)
+}
+
+//Statements in tail position are the ones whose value becomes the return value of the function:
+//the last statement of the body, and recursively the last statements of the branches of an if in that place.
+func evalTailStatement(statement ast.Statement, env *object.Environment) object.Object {
+	es, ok := statement.(*ast.ExpressionStatement)
+	if !ok {
+		return Eval(statement, env)
+	}
+	switch node := es.Expression.(type) {
+	case *ast.CallExpression:
+		return evalCallExpression(node, env, true)
+	case *ast.IfExpression:
+		return evalIfExpression(node, env, true)
+	default:
+		return Eval(node, env)
+	}
+}
+
 //This function evaluates body of the function wrt the given arguments.
+//It works as a trampoline: as long as the body ends in a tail call, the loop goes around again with the
+//callee and its arguments, so tail recursion runs in constant Go stack no matter how deep it goes.
+//Frames replaced by a tail call don't show up in the stack trace of an error.
 func applyFunction(fn object.Object, args []object.Object) object.Object {
-	switch fn := fn.(type) {
-	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
-	case *object.Builtin:
-		return fn.Fn(args...)
-	default:
-		return newError("not a function: %s", fn.Type())
+	for {
+		switch f := fn.(type) {
+		case *object.Function:
+			if len(args) != len(f.Parameters) {
+				return newError("wrong number of arguments to `%s`. got=%d, expected=%d",
+					f.FrameName(), len(args), len(f.Parameters))
+			}
+			extendedEnv := extendFunctionEnv(f, args)
+			evaluated := unwrapReturnValue(evalBlockStatements(f.Body, extendedEnv, true))
+			if tc, ok := evaluated.(*tailCall); ok {
+				fn, args = tc.fn, tc.args
+				continue
+			}
+			if err, ok := evaluated.(*object.Error); ok {
+				err.Stack = append(err.Stack, f.FrameName())
+			}
+			return evaluated
+		case *object.Builtin:
+			return f.Fn(args...)
+		default:
+			return newError("not a function: %s", fn.Type())
+		}
 	}
 }
 
@@ -319,7 +742,7 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		bind(param, args[paramIdx], env)
 	}
 	return env
 }
@@ -338,6 +761,8 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -345,26 +770,167 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	}
 }
 
+//A negative index counts from the end, -1 is the last element. Anything before the first
+//or after the last element is out of bounds.
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
 	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
 
-	if idx < 0 || idx > max {
+	i, ok := normalizeIndex(idx, len(arrayObject.Elements))
+	if !ok {
 		return newError("Array Index out of bounds: [%d]", idx)
 	}
-	return arrayObject.Elements[idx]
+	return arrayObject.Elements[i]
+}
+
+//Indexing a string gives the character at that position as a string, negative indexes count from the end just like for arrays.
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+
+	i, ok := normalizeIndex(idx, len(runes))
+	if !ok {
+		return newError("String Index out of bounds: [%d]", idx)
+	}
+	return &object.String{Value: string(runes[i])}
+}
+
+//normalizeIndex turns a possibly negative index into a position from the start, ok is false when it is out of bounds.
+func normalizeIndex(idx int64, length int) (int, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+	if idx < 0 || idx >= int64(length) {
+		return 0, false
+	}
+	return int(idx), true
+}
+
+/*
+Slicing works like it does in python. 'xs[start:end:step]' takes every
+step'th element from start up to but not including end, all three can
+be left out. Negative start and end count from the end, and bounds that
+are out of range are clamped to the array instead of being an error,
+so 'xs[:100]' is just the whole array. With a negative step the
+elements are taken backwards and start and end default to the last and
+to before the first element, 'xs[::-1]' is the array reversed.
+Strings are sliced by characters. Slicing always makes a new value.
+*/
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	bounds := []ast.Expression{node.Start, node.End, node.Step}
+	values := make([]*object.Integer, len(bounds))
+	for i, bound := range bounds {
+		if bound == nil {
+			continue
+		}
+		value := Eval(bound, env)
+		if isError(value) {
+			return value
+		}
+		integer, ok := value.(*object.Integer)
+		if !ok {
+			return newError("slice indices must be INTEGER, got %s", value.Type())
+		}
+		values[i] = integer
+	}
+
+	step := int64(1)
+	if values[2] != nil {
+		step = values[2].Value
+	}
+	if step == 0 {
+		return newError("slice step cannot be zero")
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		indices := sliceIndices(len(left.Elements), values[0], values[1], step)
+		elements := make([]object.Object, len(indices))
+		for i, idx := range indices {
+			elements[i] = left.Elements[idx]
+		}
+		return &object.Array{Elements: elements}
+	case *object.String:
+		runes := []rune(left.Value)
+		indices := sliceIndices(len(runes), values[0], values[1], step)
+		sliced := make([]rune, len(indices))
+		for i, idx := range indices {
+			sliced[i] = runes[idx]
+		}
+		return &object.String{Value: string(sliced)}
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+}
+
+//sliceIndices lists the positions a slice takes from a value of the given length, start and end are nil when they were left out.
+func sliceIndices(length int, start, end *object.Integer, step int64) []int {
+	n := int64(length)
+	var from, to int64
+	if step > 0 {
+		from, to = 0, n
+	} else {
+		from, to = n-1, -1
+	}
+	if start != nil {
+		from = clampSliceBound(start.Value, n, step)
+	}
+	if end != nil {
+		to = clampSliceBound(end.Value, n, step)
+	}
+
+	//the number of positions is worked out first, stepping past the bound with a huge step would overflow
+	span, stride := to-from, uint64(step)
+	if step < 0 {
+		span, stride = from-to, -uint64(step)
+	}
+	if span <= 0 {
+		return []int{}
+	}
+	indices := make([]int, uint64(span-1)/stride+1)
+	for k := range indices {
+		indices[k] = int(from + int64(k)*step)
+	}
+	return indices
+}
+
+//clampSliceBound counts a negative bound from the end and clamps it to the range a slice going in the direction of step can use.
+func clampSliceBound(bound, length, step int64) int64 {
+	if bound < 0 {
+		bound += length
+	}
+	if step > 0 {
+		if bound < 0 {
+			return 0
+		}
+		if bound > length {
+			return length
+		}
+		return bound
+	}
+	if bound < 0 {
+		return -1
+	}
+	if bound >= length {
+		return length - 1
+	}
+	return bound
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(key)
 	if !ok {
 		return NULL
 	}
@@ -387,26 +953,25 @@ func isError(obj object.Object) bool {
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
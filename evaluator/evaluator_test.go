@@ -1,11 +1,20 @@
 package evaluator
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Neeraj-Natu/shifu/ast"
 	"github.com/Neeraj-Natu/shifu/lexer"
 	"github.com/Neeraj-Natu/shifu/object"
 	"github.com/Neeraj-Natu/shifu/parser"
+	"github.com/Neeraj-Natu/shifu/resolver"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -211,172 +220,1635 @@ func TestErrorHandling(t *testing.T) {
 			`{"name": "Monkey"}[func(x) {x}];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			"1 / 0",
+			"division by zero: 1 / 0",
+		},
+		{
+			"let zero = 0; 10 / zero + 1",
+			"division by zero: 10 / 0",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("Wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 10; c;", 20},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; let b = a * 2; b;", 10},
+		{"const a = 5; a = 6;", "cannot assign to constant a"},
+		{"const a = 5; let a = 6;", "cannot redeclare constant a"},
+		{"const a = 5; const a = 6;", "cannot redeclare constant a"},
+		{"let a = 5; const a = 6; a;", 6},
+		{"const a = 5; if (true) { let a = 6; a = 7; a; }", 7},
+		{"const a = 5; if (true) { a = 6; }", "cannot assign to constant a"},
+		{"const a = 5; let f = func() { a = 6; }; f();", "cannot assign to constant a"},
+		{"const a = 5; let f = func(a) { a = a + 1; a }; f(1) + a;", 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errorObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errorObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObj.Message)
+			}
+		}
+	}
+}
+
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let xs = [1, 2, 3]; xs[1] = 20; xs[0] + xs[1] + xs[2];", 24},
+		{"let xs = [1, 2, 3]; let ys = xs; ys[0] = 10; xs[0];", 10},
+		{`let h = {"a": 1}; h["a"] = 2; h["b"] = 3; h["a"] + h["b"];`, 5},
+		{"const xs = [1]; xs[0] = 5; xs[0];", 5},
+		{"let xs = [1]; xs[1] = 5;", "Array Index out of bounds: [1]"},
+		{"let xs = [1, 2]; xs[-1] = 5; xs[1];", 5},
+		{"let xs = [1]; xs[-2] = 5;", "Array Index out of bounds: [-2]"},
+		{`let h = {}; h[func(x) { x }] = 1;`, "unusable as hash key: FUNCTION"},
+		{`let s = "abc"; s[0] = "d";`, "index assignment not supported: STRING"},
+		{"let xs = freeze([1, 2]); xs[0] = 5;", "cannot modify frozen ARRAY"},
+		{`let h = freeze({"a": 1}); h["a"] = 5;`, "cannot modify frozen HASH"},
+		{`let h = freeze({"a": [1, {"b": 2}]}); h["a"][0] = 5;`, "cannot modify frozen ARRAY"},
+		{`let h = freeze({"a": [1, {"b": 2}]}); h["a"][1]["b"] = 5;`, "cannot modify frozen HASH"},
+		{"let xs = [1, 2]; let ys = freeze(xs); xs[0] = 5;", "cannot modify frozen ARRAY"},
+		{"let xs = freeze([1, 2]); let ys = push(xs, 3); ys[0] = 5; ys[0] + xs[0];", 6},
+		{"let xs = [1]; xs[0] = xs; freeze(xs); len(xs);", 1},
+		{"freeze(5);", 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errorObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errorObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObj.Message)
+			}
+		}
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "func(x) { x + 2;};"
+
+	evaluated := testEval(input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not a Function. got=%T (%+v)", evaluated, evaluated)
+	}
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("Function has wrong parameters. Parameters=%+v", fn.Parameters[0])
+	}
+
+	expectedBody := "(x + 2)"
+	if fn.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let iden = func(x) {x;}; iden(5);", 5},
+		{"let iden = func(x) {return x;};iden(5);", 5},
+		{"let double = func(x) { x * 2; };double(5);", 10},
+		{"let add = func(x, y) { x + y; };add(5, 5);", 10},
+		{"let add = func(x, y) { x + y; }; add(5 + 5, add(5,5));", 20},
+		{"func(x) {x;}(5)", 5},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"func double(x) { x * 2 }; double(5);", 10},
+		{"let result = double(5); func double(x) { x * 2 } result;", 10},
+		{"func add(x, y) { return x + y; } add(2, 3);", 5},
+		{`
+		func isEven(n) { if (n == 0) { true } else { isOdd(n - 1) } }
+		func isOdd(n) { if (n == 0) { false } else { isEven(n - 1) } }
+		if (isEven(10)) { 1 } else { 0 }`, 1},
+		{`
+		let outer = func() {
+			let result = helper(3);
+			func helper(x) { x + inner(); }
+			func inner() { 4 }
+			result;
+		};
+		outer();`, 7},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionNames(t *testing.T) {
+	evaluated := testEval("func add(x, y) { x + y } add;")
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not a Function. got=%T (%+v)", evaluated, evaluated)
+	}
+	if fn.Name != "add" {
+		t.Errorf("function has wrong name. got=%q", fn.Name)
+	}
+
+	expected := "func add(x, y) {\n(x + y)\n}"
+	if fn.Inspect() != expected {
+		t.Errorf("function Inspect is wrong. expected=%q, got=%q", expected, fn.Inspect())
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+		expectedStack   []string
+	}{
+		{
+			"func add(x, y) { x + y } add(1);",
+			"wrong number of arguments to `add`. got=1, expected=2",
+			nil,
+		},
+		{
+			"func(x) { x }(1, 2);",
+			"wrong number of arguments to `anonymous function`. got=2, expected=1",
+			nil,
+		},
+		{
+			`func inner() { 1 + true } func outer() { inner() + 1 } outer();`,
+			"type mismatch: INTEGER + BOOLEAN",
+			[]string{"inner", "outer"},
+		},
+		{
+			`func inner() { 1 + true } func outer() { inner() } outer();`,
+			"type mismatch: INTEGER + BOOLEAN",
+			[]string{"inner"},
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("Wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+		if len(errObj.Stack) != len(tt.expectedStack) {
+			t.Errorf("Wrong stack trace. expected=%v, got=%v", tt.expectedStack, errObj.Stack)
+			continue
+		}
+		for i, frame := range tt.expectedStack {
+			if errObj.Stack[i] != frame {
+				t.Errorf("Wrong stack frame %d. expected=%q, got=%q", i, frame, errObj.Stack[i])
+			}
+		}
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`
+		let sum = func(n, acc) { if (n == 0) { acc } else { sum(n - 1, acc + n) } };
+		sum(1000000, 0);`, 500000500000},
+		{`
+		func count(n) {
+			if (n == 0) {
+				return 0;
+			}
+			return count(n - 1);
+		}
+		count(1000000);`, 0},
+		{`
+		func isEven(n) { if (n == 0) { true } else { isOdd(n - 1) } }
+		func isOdd(n) { if (n == 0) { false } else { isEven(n - 1) } }
+		if (isEven(1000000)) { 1 } else { 0 }`, 1},
+		{`
+		let fact = func(n) { if (n == 0) { 1 } else { n * fact(n - 1) } };
+		fact(20);`, 2432902008176640000},
+	}
+
+	//Without tail calls reusing the frame a million nested calls need far more than this much stack,
+	//and blowing it is a fatal error that takes the whole test binary down.
+	defer debug.SetMaxStack(debug.SetMaxStack(16 << 20))
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEnclosingEnvironments(t *testing.T) {
+	input := `
+let first = 10;
+let second = 10;
+let third = 10;
+
+let ourFunction = func(first) {
+  let second = 20;
+
+  first + second + third;
+};
+
+ourFunction(20) + first + second;`
+
+	testIntegerObject(t, testEval(input), 70)
+}
+
+func TestClosures(t *testing.T) {
+	input := `
+	let newAdder = func(x){
+		func(y) { x + y };
+	};
+	
+	let addTwo = newAdder(2);
+	addTwo(2);
+	`
+	testIntegerObject(t, testEval(input), 4)
+}
+
+func TestClosuresSharingMutableState(t *testing.T) {
+	input := `
+	let newCounter = func() {
+		let count = 0;
+		func() { count = count + 1; count };
+	};
+
+	let first = newCounter();
+	let second = newCounter();
+	first(); first(); first();
+	second();
+	first() * 10 + second();
+	`
+	testIntegerObject(t, testEval(input), 42)
+}
+
+func TestClosuresCapturePerIteration(t *testing.T) {
+	input := `
+	let fns = [];
+	for (x in [1, 2, 3]) {
+		let double = x * 2;
+		fns = push(fns, func() { x * 100 + double });
+	}
+	fns[0]() + fns[1]() + fns[2]();
+	`
+	testIntegerObject(t, testEval(input), 612)
+}
+
+func TestBlockScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; if (true) { let x = 2; }; x;", 1},
+		{"let x = 1; if (true) { let x = 2; x; }", 2},
+		{"let x = 1; if (false) { 0 } else { let x = 3; }; x;", 1},
+		{"if (true) { let y = 2; }; y;", "variable not found: y"},
+		{"let x = 1; if (true) { x = 2; }; x;", 2},
+		{"let x = 1; if (true) { let x = 2; x = 3; }; x;", 1},
+		{"let x = 1; let f = func() { let x = 5; x = x + 1; x }; f() + x;", 7},
+		{"let x = 1; let f = func(x) { x = x + 1; x }; f(10) + x;", 12},
+		{"let x = 1; x = x + 1; let x = x + 1; x;", 3},
+		{"y = 1;", "cannot assign to undeclared variable: y"},
+		{"let f = func() { let z = 1; }; f(); z = 2;", "cannot assign to undeclared variable: z"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errorObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errorObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObj.Message)
+			}
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 10) { i = i + 1; }; i;", 10},
+		{"let i = 0; while (false) { i = i + 1; }; i;", 0},
+		{"let total = 0; for (x in [1, 2, 3, 4]) { total = total + x; }; total;", 10},
+		{"let total = 0; for (x in []) { total = total + 1; }; total;", 0},
+		{`let s = ""; for (c in "abc") { s = c + s; }; len(s);`, 3},
+		{`let total = 0; for (k in {1: "a", 2: "b"}) { total = total + k; }; total;`, 3},
+		{"let x = 5; for (x in [1, 2]) { x; }; x;", 5},
+		{"for (x in [1, 2]) { let y = x; }; y;", "variable not found: y"},
+		{"let find = func(xs) { for (x in xs) { if (x > 2) { return x; } }; 0 }; find([1, 2, 3, 4]);", 3},
+		{"let i = 0; while (i < 3) { i = i + 1; if (i == 2) { return i * 10; } }; i;", 20},
+		{"for (x in 5) { x; }", "cannot iterate over INTEGER"},
+		{"for (x in [1, 2]) { x + true; }", "type mismatch: INTEGER + BOOLEAN"},
+		{"while (1 + true) { 1; }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errorObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errorObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObj.Message)
+			}
+		}
+	}
+}
+
+func TestResolvedAndUnresolvedAgree(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let f = func(x, y) { let z = x * y; z + x }; f(3, 4);", 15},
+		{"let x = 1; let f = func() { let x = 2; if (true) { let x = 3; x = x + 1; }; x }; f() + x;", 3},
+		{"let total = 0; for (x in [1, 2, 3]) { let y = x * x; total = total + y; }; total;", 14},
+		{"let f = func() { let i = 0; while (i < 5) { i = i + 1; }; i }; f();", 5},
+		{"let f = func(n) { func g(x) { if (x == 0) { n } else { g(x - 1) } }; g(3) }; f(7);", 7},
+		{"let f = func() { let later = func() { value * 2 }; let value = 21; later() }; f();", 42},
+		{"let newAdder = func(x) { func(y) { x + y } }; newAdder(2)(3);", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEvalUnresolved(tt.input), tt.expected)
+	}
+}
+
+const benchmarkInput = `
+let fib = func(n) {
+	let a = 0;
+	let b = 1;
+	let i = 0;
+	while (i < n) {
+		let next = a + b;
+		a = b;
+		b = next;
+		i = i + 1;
+	};
+	a
+};
+fib(1000);
+`
+
+func BenchmarkEvalResolved(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		testEval(benchmarkInput)
+	}
+}
+
+func BenchmarkEvalUnresolved(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		testEvalUnresolved(benchmarkInput)
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	input := `"Inner Peace!"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "Inner Peace!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	input := ` "Inner " + "" + " Peace!" `
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "Inner  Peace!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one","two")`, "wrong number of arguments. got=2, expected=1"},
+		{`len([1, 2, 3])`, 3},
+		{`len([])`, 0},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, "an array has no elements!"},
+		{`first(1)`, "argument to 'first' must be an ARRAY, got INTEGER"},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, "an array has no elements!"},
+		{`last(1)`, "argument to 'last' must be an ARRAY, got INTEGER"},
+		{`push([], 1)`, []int{1}},
+		{`push(1, 1)`, "argument to 'push' must be an ARRAY, got INTEGER"},
+		{`pop([1, 2, 3], 1)`, []int{1, 3}},
+		{`pop([1, 2, 3], 2)`, []int{1, 2}},
+		{`pop(1, 1)`, "argument to 'pop' must be an ARRAY, got INTEGER"},
+		{`pop([], [])`, "second argument to 'pop' must be an INTEGER, got ARRAY"},
+		{`pop([1, 2, 3], -1)`, []int{1, 2}},
+		{`pop([1, 2, 3], -3)`, []int{2, 3}},
+		{`pop([1, 2, 3], -4)`, "Index to pop from is out of bounds!"},
+		{`pop([1, 2, 3])`, []int{1, 2}},
+		{`pop([])`, "Index to pop from is out of bounds!"},
+		{`pop()`, "wrong number of arguments. got=0, expected=1 or 2"},
+		{`pop([1], 0, 0)`, "wrong number of arguments. got=3, expected=1 or 2"},
+		{`pop([1, 2, 3], 3)`, "Index to pop from is out of bounds!"},
+		{`pop([1, 2, 3], 6)`, "Index to pop from is out of bounds!"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errorObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errorObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObj.Message)
+			}
+		case []int:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong number of elements for %q. expected=%d, got=%d", tt.input, len(expected), len(array.Elements))
+				continue
+			}
+			for i, el := range expected {
+				testIntegerObject(t, array.Elements[i], int64(el))
+			}
+		}
+	}
+}
+
+func TestArraysDontAlias(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let a = [1, 2, 3]; let b = pop(a, 0); [a, b]`, "[[1, 2, 3], [2, 3]]"},
+		{`let a = [1, 2, 3]; let b = pop(a, 1); [a, b]`, "[[1, 2, 3], [1, 3]]"},
+		{`let a = [1, 2, 3]; let b = pop(a); [a, b]`, "[[1, 2, 3], [1, 2]]"},
+		{`let a = push([1], 2); let b = push(a, 3); let c = push(a, 4); [a, b, c]`, "[[1, 2], [1, 2, 3], [1, 2, 4]]"},
+		{`let a = push([1], 2); let b = push(a, 3); b[0] = 9; [a, b]`, "[[1, 2], [9, 2, 3]]"},
+		{`let a = push([1], 2); let b = push(a, 3); a[0] = 9; [a, b]`, "[[9, 2], [1, 2, 3]]"},
+		{`let a = push([1], 2); let b = pop(a); let c = push(b, 5); [a, b, c]`, "[[1, 2], [1], [1, 5]]"},
+		{`let a = push([1], 2); let b = pop(a); b[0] = 7; [a, b]`, "[[1, 2], [7]]"},
+		{`let a = push([1], 2); let b = push(a, 3); let c = freeze(a); b[1] = 0; [c, b]`, "[[1, 2], [1, 0, 3]]"},
+		{`let xs = []; let i = 0; while (i < 5) { xs = push(xs, i); i = i + 1; }; let ys = push(xs, 5); xs[0] = 9; [xs, ys]`,
+			"[[9, 1, 2, 3, 4], [0, 1, 2, 3, 4, 5]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCollectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`len({"a": 1, "b": 2})`, "2"},
+		{`keys({"b": 1, "a": 2})`, "[b, a]"},
+		{`keys({})`, "[]"},
+		{`keys([1])`, "ERROR: argument to 'keys' must be a HASH, got ARRAY"},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`values(1, 2)`, "ERROR: wrong number of arguments. got=2, expected=1"},
+		{`has({"a": 1}, "a")`, "true"},
+		{`has({"a": 1}, "b")`, "false"},
+		{`has({freeze([1]): 1}, freeze([1]))`, "true"},
+		{`has({"a": 1}, [1])`, "ERROR: unusable as hash key: ARRAY"},
+		{`has([1], 1)`, "ERROR: argument to 'has' must be a HASH or a SET, got ARRAY"},
+		{`delete({"a": 1, "b": 2, "c": 3}, "b")`, "{a: 1, c: 3}"},
+		{`delete({"a": 1}, "z")`, "{a: 1}"},
+		{`let h = {"a": 1}; delete(h, "a"); h`, "{a: 1}"},
+		{`delete(freeze({"a": 1, "b": 2}), "a")`, "{b: 2}"},
+		{`delete({"a": 1}, func() { 1 })`, "ERROR: unusable as hash key: FUNCTION"},
+		{`delete({"a": 1})`, "ERROR: wrong number of arguments. got=1, expected=2"},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+		{`merge({"a": 1}, {}, {"a": 5})`, "{a: 5}"},
+		{`let h = {"a": 1}; merge(h, {"a": 2}); h`, "{a: 1}"},
+		{`merge()`, "ERROR: wrong number of arguments. got=0, expected at least 1"},
+		{`merge({}, [])`, "ERROR: second argument to 'merge' must be a HASH, got ARRAY"},
+		{`slice([1, 2, 3, 4], 1, 3)`, "[2, 3]"},
+		{`slice([1, 2, 3, 4], 2)`, "[3, 4]"},
+		{`slice([1, 2, 3], 3)`, "[]"},
+		{`slice([1, 2, 3], 0, 0)`, "[]"},
+		{`let xs = [1, 2, 3]; let ys = slice(xs, 0, 2); ys[0] = 9; xs`, "[1, 2, 3]"},
+		{`slice([1, 2, 3], 2, 1)`, "ERROR: slice bounds out of range: [2:1] with length 3"},
+		{`slice([1, 2, 3], 0, 4)`, "ERROR: slice bounds out of range: [0:4] with length 3"},
+		{`slice([1, 2, 3], -1)`, "ERROR: slice bounds out of range: [-1:3] with length 3"},
+		{`slice([1], "a")`, "ERROR: second argument to 'slice' must be an INTEGER, got STRING"},
+		{`slice([1], 0, "a")`, "ERROR: third argument to 'slice' must be an INTEGER, got STRING"},
+		{`slice([1])`, "ERROR: wrong number of arguments. got=1, expected=2 or 3"},
+		{`concat([1], [2, 3], [], [4])`, "[1, 2, 3, 4]"},
+		{`concat()`, "[]"},
+		{`concat([1], 2)`, "ERROR: second argument to 'concat' must be an ARRAY, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], func(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map([], func(x) { x })`, "[]"},
+		{`map([[1], [1, 2]], len)`, "[1, 2]"},
+		{`let xs = [1, 2]; map(xs, func(x) { x + 1 }); xs`, "[1, 2]"},
+		{`map([1], 2)`, "ERROR: second argument to 'map' must be a FUNCTION, got INTEGER"},
+		{`map(1, len)`, "ERROR: argument to 'map' must be an ARRAY, got INTEGER"},
+		{`map([1], func(x, y) { x })`, "ERROR: wrong number of arguments to `anonymous function`. got=1, expected=2"},
+		{`map([1, 2], func(x) { if (x == 2) { x + "a" } else { x } })`, "ERROR: type mismatch: INTEGER + STRING\n\tat anonymous function"},
+		{`filter([1, 2, 3, 4], func(x) { x > 2 })`, "[3, 4]"},
+		{`filter([1, if (false) { 1 }, 2, false], func(x) { x })`, "[1, 2]"},
+		{`reduce([1, 2, 3], func(acc, x) { acc + x })`, "6"},
+		{`reduce([1, 2, 3], func(acc, x) { acc + x }, 10)`, "16"},
+		{`reduce([], func(acc, x) { acc + x }, 0)`, "0"},
+		{`reduce(["a", "b"], func(acc, x) { push(acc, x) }, [])`, "[a, b]"},
+		{`reduce([], func(acc, x) { acc + x })`, "ERROR: reduce of an empty array with no initial value"},
+		{`reduce([1])`, "ERROR: wrong number of arguments. got=1, expected=2 or 3"},
+		{`find([1, 2, 3, 4], func(x) { x > 1 })`, "2"},
+		{`find([1, 2], func(x) { x > 5 })`, "null"},
+		{`any([1, 2, 3], func(x) { x > 2 })`, "true"},
+		{`any([], func(x) { true })`, "false"},
+		{`all([1, 2, 3], func(x) { x > 0 })`, "true"},
+		{`all([1, 2, 3], func(x) { x > 1 })`, "false"},
+		{`all([], func(x) { false })`, "true"},
+		{`let n = 0; any([1, 2, 3], func(x) { n = n + 1; x == 2 }); n`, "2"},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`sort([])`, "[]"},
+		{`let xs = [2, 1]; sort(xs); xs`, "[2, 1]"},
+		{`sort([3, 1, 2], func(a, b) { b - a })`, "[3, 2, 1]"},
+		{`sort(["ccc", "a", "bb", "d"], len)`, "[a, d, bb, ccc]"},
+		{`sort([[2, "a"], [1, "b"], [2, "c"], [1, "d"]], func(p) { p[0] })`, "[[1, b], [1, d], [2, a], [2, c]]"},
+		{`sort([[2, "a"], [1, "b"], [2, "c"], [1, "d"]], func(a, b) { a[0] - b[0] })`, "[[1, b], [1, d], [2, a], [2, c]]"},
+		{`sort([1, "a"])`, "ERROR: cannot compare STRING and INTEGER"},
+		{`sort([1, 2], func(a, b) { true })`, "ERROR: comparator passed to 'sort' must return an INTEGER, got BOOLEAN"},
+		{`sort([1, 2], 1)`, "ERROR: second argument to 'sort' must be a FUNCTION, got INTEGER"},
+		{`sort([1, 2], func(x) { x }, 1)`, "ERROR: wrong number of arguments. got=3, expected=1 or 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSortIsStable(t *testing.T) {
+	// enough elements that sorting doesn't just fall back to insertion sort
+	input := `
+	let xs = [];
+	let i = 0;
+	while (i < 200) { xs = push(xs, [i - (i / 7) * 7, i]); i = i + 1; }
+	let sorted = sort(xs, func(p) { p[0] });
+	all(map([0, 1, 2, 3, 4, 5, 6], func(k) {
+		let group = map(filter(sorted, func(p) { p[0] == k }), func(p) { p[1] });
+		group == sort(group)
+	}), func(ok) { ok })`
+
+	testBooleanObject(t, testEval(input), true)
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`len("héllo")`, "5"},
+		{`len("日本語")`, "3"},
+		{`split("a,b,,c", ",")`, "[a, b, , c]"},
+		{`split("  a  b c ")`, "[a, b, c]"},
+		{`split("日本", "")`, "[日, 本]"},
+		{`split("abc", 1)`, "ERROR: second argument to 'split' must be a STRING, got INTEGER"},
+		{`join(["a", "b", "c"], ", ")`, "a, b, c"},
+		{`join(["a", "b"])`, "ab"},
+		{`join([])`, ""},
+		{`join(["a", 1])`, "ERROR: elements of the array passed to 'join' must be STRING, got INTEGER"},
+		{`join("a")`, "ERROR: argument to 'join' must be an ARRAY, got STRING"},
+		{`trim("  a b  ")`, "a b"},
+		{`trim("xxaxyx", "xy")`, "a"},
+		{`upper("héllo")`, "HÉLLO"},
+		{`lower("ÀB")`, "àb"},
+		{`upper()`, "ERROR: wrong number of arguments. got=0, expected=1"},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`replace("abc", "x", "y")`, "abc"},
+		{`contains("héllo", "él")`, "true"},
+		{`contains("hello", "x")`, "false"},
+		{`startsWith("hello", "he")`, "true"},
+		{`startsWith("hello", "lo")`, "false"},
+		{`endsWith("hello", "lo")`, "true"},
+		{`indexOf("héllo", "l")`, "2"},
+		{`indexOf("hello", "z")`, "-1"},
+		{`indexOf([1, [2], 3], [2])`, "1"},
+		{`indexOf([1, 2], 5)`, "-1"},
+		{`indexOf(1, 1)`, "ERROR: argument to 'indexOf' must be an ARRAY or a STRING, got INTEGER"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`repeat("ab", -1)`, "ERROR: negative repeat count: -1"},
+		{`"abc".repeat(9223372036854775807)`, "ERROR: string too long: repeating 3 bytes 9223372036854775807 times"},
+		{`repeat("", 9223372036854775807)`, ""},
+		{`"x".padLeft(9223372036854775807, "y")`, "ERROR: width passed to 'padLeft' is too big: 9223372036854775807"},
+		{`"x".padRight(-5)`, "x"},
+		{`padLeft("7", 3, "0")`, "007"},
+		{`padLeft("é", 3)`, "  é"},
+		{`padRight("ab", 5, "xy")`, "abxyx"},
+		{`padRight("abcdef", 3)`, "abcdef"},
+		{`padLeft("a", 3, "")`, "ERROR: padding passed to 'padLeft' must not be empty"},
+		{`padLeft("a")`, "ERROR: wrong number of arguments. got=1, expected=2 or 3"},
+		{`slice("héllo", 1, 3)`, "él"},
+		{`slice("héllo", 3)`, "lo"},
+		{`slice("héllo", 0, 6)`, "ERROR: slice bounds out of range: [0:6] with length 5"},
+		{`slice(1, 0)`, "ERROR: argument to 'slice' must be an ARRAY or a STRING, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStringOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a" == "a"`, "true"},
+		{`"a" != "a"`, "false"},
+		{`"a" == "b"`, "false"},
+		{`"a" < "b"`, "true"},
+		{`"b" < "a"`, "false"},
+		{`"abc" > "abd"`, "false"},
+		{`"ab" < "abc"`, "true"},
+		{`"z" < "é"`, "true"},
+		{`"ab" * 3`, "ababab"},
+		{`3 * "ab"`, "ababab"},
+		{`"ab" * 0`, ""},
+		{`"é" * 2`, "éé"},
+		{`"ab" * -1`, "ERROR: negative repeat count: -1"},
+		{`"ab" * 9223372036854775807`, "ERROR: string too long: repeating 2 bytes 9223372036854775807 times"},
+		{`"ab" - "a"`, "ERROR: unknown operator: STRING - STRING"},
+		{`"ab" * "a"`, "ERROR: unknown operator: STRING * STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMethodCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2].push(3)`, "[1, 2, 3]"},
+		{`[3, 1, 2].sort().map(func(x) { x * 10 })`, "[10, 20, 30]"},
+		{`[1, 2, 3].filter(func(x) { x > 1 }).len()`, "2"},
+		{`"héllo".upper()`, "HÉLLO"},
+		{`"a,b".split(",").join("-")`, "a-b"},
+		{`let s = "abc"; s.len()`, "3"},
+		{`{"b": 1, "a": 2}.keys()`, "[b, a]"},
+		{`let h = {"a": 1}; h.has("a")`, "true"},
+		{`let xs = [1]; let push = xs.push; push(2)`, "[1, 2]"},
+		{`let len = func(x) { 0 }; "abc".len()`, "3"},
+		{`1.5.floor()`, "1"},
+		{`(-2.5).abs().round()`, "3"},
+		{`2.25.ceil()`, "3"},
+		{`1.5.len()`, "ERROR: unknown member: FLOAT.len"},
+		{`[7].choice()`, "7"},
+		{`[1, 2, 3].shuffle().sort()`, "[1, 2, 3]"},
+		{`1.len()`, "ERROR: unknown member: INTEGER.len"},
+		{`[1].upper()`, "ERROR: unknown member: ARRAY.upper"},
+		{`[1].len(2)`, "ERROR: wrong number of arguments. got=2, expected=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHashMembers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let config = {"name": "shifu", "retries": 3}; config.name`, "shifu"},
+		{`let config = {"db": {"host": "localhost"}}; config.db.host`, "localhost"},
+		{`let config = {}; config.missing`, "null"},
+		{`let h = {"keys": 5}; h.keys`, "5"},
+		{`let h = {"a": 1}; h.keys()`, "[a]"},
+		{`let h = {"double": func(x) { x * 2 }}; h.double(4)`, "8"},
+		{`let h = {"a": 1}; h.a = 2; h.b = 3; h`, "{a: 2, b: 3}"},
+		{`let h = {"db": {}}; h.db.port = 5432; h["db"]["port"]`, "5432"},
+		{`let h = freeze({"a": 1}); h.a = 2;`, "ERROR: cannot modify frozen HASH"},
+		{`let xs = [1]; xs.a = 2;`, "ERROR: cannot assign to member of ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#{1, 2, 3}`, "#{1, 2, 3}"},
+		{`#{3, 1, 3, 2, 1}`, "#{3, 1, 2}"},
+		{`#{}`, "#{}"},
+		{`#{"a", true, freeze([1, 2])}`, "#{a, true, [1, 2]}"},
+		{`#{[1]}`, "ERROR: unusable as set element: ARRAY"},
+		{`#{1, 2} | #{2, 3}`, "#{1, 2, 3}"},
+		{`#{1, 2, 3} & #{3, 2, 5}`, "#{2, 3}"},
+		{`#{1, 2, 3} - #{2}`, "#{1, 3}"},
+		{`#{1, 2} | #{3} & #{3, 4}`, "#{1, 2, 3}"},
+		{`#{1, 2} == #{2, 1}`, "true"},
+		{`#{1, 2} != #{1}`, "true"},
+		{`#{1} == [1]`, "false"},
+		{`#{1} + #{2}`, "ERROR: unknown operator: SET + SET"},
+		{`#{1} | [2]`, "ERROR: type mismatch: SET | ARRAY"},
+		{`2 in #{1, 2}`, "true"},
+		{`5 in #{1, 2}`, "false"},
+		{`[1] in #{1}`, "ERROR: unusable as set element: ARRAY"},
+		{`len(#{1, 2, 2})`, "2"},
+		{`has(#{"a"}, "a")`, "true"},
+		{`#{1, 2}.has(3)`, "false"},
+		{`let total = 0; for (x in #{1, 2, 2, 3}) { total = total + x; }; total`, "6"},
+		{`set([3, 1, 3])`, "#{3, 1}"},
+		{`set("hello")`, "#{h, e, l, o}"},
+		{`set({"a": 1, "b": 2})`, "#{a, b}"},
+		{`set()`, "#{}"},
+		{`set(1)`, "ERROR: argument to 'set' must be an ARRAY, a STRING or a HASH, got INTEGER"},
+		{`set([[1]])`, "ERROR: unusable as set element: ARRAY"},
+		{`union(#{1}, #{2}, #{3, 1})`, "#{1, 2, 3}"},
+		{`intersection(#{1, 2, 3}, #{2, 3}, #{3})`, "#{3}"},
+		{`difference(#{1, 2, 3}, #{1}, #{3})`, "#{2}"},
+		{`#{1, 2}.union(#{4})`, "#{1, 2, 4}"},
+		{`union(#{1})`, "ERROR: wrong number of arguments. got=1, expected at least 2"},
+		{`union(#{1}, [2])`, "ERROR: second argument to 'union' must be a SET, got ARRAY"},
+		{`let s = #{1}; let t = s | #{2}; s`, "#{1}"},
+		{`{#{1}: 2}`, "ERROR: unusable as hash key: SET"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTypeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`type(1)`, "INTEGER"},
+		{`type("a")`, "STRING"},
+		{`type(true)`, "BOOLEAN"},
+		{`type([])`, "ARRAY"},
+		{`type({})`, "HASH"},
+		{`type(#{})`, "SET"},
+		{`type(if (false) { 1 })`, "NULL"},
+		{`type(func() { 1 })`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`type()`, "ERROR: wrong number of arguments. got=0, expected=1"},
+		{`int("42")`, "42"},
+		{`int("-7")`, "-7"},
+		{`int(5)`, "5"},
+		{`int(true)`, "1"},
+		{`int(false)`, "0"},
+		{`int("abc")`, `ERROR: cannot convert "abc" to INTEGER`},
+		{`int("4 2")`, `ERROR: cannot convert "4 2" to INTEGER`},
+		{`int("99999999999999999999")`, `ERROR: cannot convert "99999999999999999999" to INTEGER`},
+		{`int([1])`, "ERROR: cannot convert ARRAY to INTEGER"},
+		{`str(5)`, "5"},
+		{`str("a")`, "a"},
+		{`str([1, "a"])`, "[1, a]"},
+		{`str(5) + "!"`, "5!"},
+		{`bool(0)`, "true"},
+		{`bool(false)`, "false"},
+		{`bool(if (false) { 1 })`, "false"},
+		{`bool("")`, "true"},
+		{`is_int(1)`, "true"},
+		{`is_int("1")`, "false"},
+		{`is_string("1")`, "true"},
+		{`is_bool(false)`, "true"},
+		{`is_array([])`, "true"},
+		{`is_hash({})`, "true"},
+		{`is_set(#{})`, "true"},
+		{`is_null(if (false) { 1 })`, "true"},
+		{`is_function(len)`, "true"},
+		{`is_function(func() { 1 })`, "true"},
+		{`is_function(1)`, "false"},
+		{`repr("a")`, `"a"`},
+		{`repr(1)`, "1"},
+		{`repr([1, "a", true])`, `[1, "a", true]`},
+		{`repr({"k": ["v"]})`, `{"k": ["v"]}`},
+		{`repr(#{"a"})`, `#{"a"}`},
+		{`repr("say \"hi\"\n")`, `"say \"hi\"\n"`},
+		{`let xs = [1]; xs[0] = xs; repr(xs)`, "[...]"},
+		{`repr(freeze([1, [2]]))`, "freeze([1, [2]])"},
+		{`repr([freeze({"a": 1})])`, `[freeze({"a": 1})]`},
+		{`repr(#{freeze([1])})`, "#{freeze([1])}"},
+		{`type(1.5)`, "FLOAT"},
+		{`is_float(1.5)`, "true"},
+		{`is_float(1)`, "false"},
+		{`int(2.9)`, "2"},
+		{`int(-2.9)`, "-2"},
+		{`float(2)`, "2.0"},
+		{`float("2.5")`, "2.5"},
+		{`float("x")`, `ERROR: cannot convert "x" to FLOAT`},
+		{`repr(1.25)`, "1.25"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+//Evaluating what repr returns gives back an equal value.
+func TestReprRoundTrips(t *testing.T) {
+	inputs := []string{
+		`"plain"`,
+		`"quote \" backslash \\ newline \n tab \t"`,
+		`[1, -2, "three", [true, false]]`,
+		`{"a": 1, "b": {"c": ["d"]}, 3: "e", true: #{1, "x"}}`,
+		`#{"a", 2, freeze([3])}`,
+		`[freeze({"a": [1]}), {freeze([1, 2]): 3}]`,
+		`[1.5, -0.25, 2.0]`,
+		`[1e21, 1.5e-7, INF, -INF, 1000000000000000000000.0]`,
+		`json_parse("[123456789012345678901234567890.5, 1e300]")`,
+		`[regex("a+"), date(2024, 1, 2, 3, 4, 5), parse_time("2024-05-01T12:00:00.5+02:00"), duration("90s")]`,
+	}
+
+	for _, input := range inputs {
+		value := testEval(input)
+		source := testEval("repr(" + input + ")").Inspect()
+		again := testEval(source)
+		if !object.Equals(value, again) {
+			t.Errorf("repr didn't round trip for %s. repr=%s, evaluated to %s", input, source, again.Inspect())
+		}
+	}
+}
+
+func TestFloats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1.5`, "1.5"},
+		{`2.0`, "2.0"},
+		{`-1.5`, "-1.5"},
+		{`1.5 + 1.25`, "2.75"},
+		{`1 + 0.5`, "1.5"},
+		{`3 / 2.0`, "1.5"},
+		{`0.5 * 4`, "2.0"},
+		{`1.5 < 2`, "true"},
+		{`2 > 2.5`, "false"},
+		{`1 == 1.0`, "true"},
+		{`1.5 != 1.5`, "false"},
+		{`sort([2, 0.5, 1])`, "[0.5, 1, 2]"},
+		{`1.5 + "a"`, "ERROR: type mismatch: FLOAT + STRING"},
+		{`1e21`, "1e+21"},
+		{`2.5e-3 * 2`, "0.005"},
+		{`1.0 / 0`, "INF"},
+		{`-1.0 / 0`, "-INF"},
+		{`repr([NAN, -INF])`, "[NAN, -INF]"},
+		{`NAN == NAN`, "false"},
+		{`1 in [1.0]`, "true"},
+		{`1.5 in [1, 2]`, "false"},
+		{`[1] == [1.0]`, "true"},
+		{`{"a": 1} == {"a": 1.0}`, "true"},
+		{`9007199254740993 == 9007199254740992.0`, "false"},
+		{`has({1: 2}, 1.0)`, "true"},
+		{`{1: "a"}[1.0]`, "a"},
+		{`{1.5: "a"}[1.5]`, "a"},
+		{`let h = {1: "a"}; h[1.0] = "b"; h`, "{1: b}"},
+		{`1.0 in #{1, 2}`, "true"},
+		{`#{1, 1.0, 2.5}`, "#{1, 2.5}"},
+		{`#{freeze([1])} == #{freeze([1.0])}`, "true"},
+		{`{NAN: 1}`, "ERROR: unusable as hash key: FLOAT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestJSONBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json_parse("{\"b\": 1, \"a\": [true, null, 1.5, \"x\"]}")`, "{b: 1, a: [true, null, 1.5, x]}"},
+		{`json_parse("[]")`, "[]"},
+		{`json_parse("-12")`, "-12"},
+		{`json_parse("1e2")`, "100.0"},
+		{`json_parse("\"a\\u00e9\"")`, "aé"},
+		{`json_parse("{\"a\": 1, \"a\": 2}")["a"]`, "2"},
+		{`json_parse("9223372036854775807")`, "9223372036854775807"},
+		{`json_parse("9223372036854775808")`, "ERROR: JSON number 9223372036854775808 is too big for an INTEGER"},
+		{`json_parse("[1,")`, "ERROR: invalid JSON: unexpected end of JSON input"},
+		{`json_parse("")`, "ERROR: invalid JSON: unexpected end of JSON input"},
+		{`json_parse("1 2")`, "ERROR: invalid JSON: unexpected data after the value"},
+		{`json_parse("{1: 2}")`, "ERROR: invalid JSON: object member name must be a string"},
+		{`json_parse(1)`, "ERROR: argument to 'json_parse' must be a STRING, got INTEGER"},
+		{`json_stringify({1: "a", "1": "b"})`, `ERROR: cannot convert HASH to JSON, more than one key is written as "1"`},
+		{`json_stringify({1: "a", "2": "b"})`, `{"1":"a","2":"b"}`},
+		{`json_stringify({"b": 1, "a": [true, 1.5, "x<y"]})`, `{"b":1,"a":[true,1.5,"x<y"]}`},
+		{`json_stringify({1: if (false) { 1 }})`, `{"1":null}`},
+		{`json_stringify("say \"hi\"\n")`, `"say \"hi\"\n"`},
+		{`json_stringify(9223372036854775807)`, "9223372036854775807"},
+		{`json_stringify(#{1, 2})`, "[1,2]"},
+		{`json_stringify({"a": [1, 2], "b": {}}, 2)`, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}"},
+		{`json_stringify([1], "\t")`, "[\n\t1\n]"},
+		{`json_stringify([1], true)`, "ERROR: second argument to 'json_stringify' must be an INTEGER or a STRING, got BOOLEAN"},
+		{`json_stringify([1], -1)`, "ERROR: negative indent: -1"},
+		{`json_stringify([1], 9223372036854775807)`, "ERROR: indent too big: 9223372036854775807"},
+		{`json_stringify([len])`, "ERROR: cannot convert BUILTIN to JSON"},
+		{`json_stringify(func(x) { x })`, "ERROR: cannot convert FUNCTION to JSON"},
+		{`json_stringify({true: 1})`, "ERROR: cannot convert BOOLEAN key to JSON"},
+		{`let xs = [1]; xs[0] = xs; json_stringify(xs)`, "ERROR: cannot convert ARRAY that contains itself to JSON"},
+		{`let h = {}; h["h"] = [h]; json_stringify(h)`, "ERROR: cannot convert HASH that contains itself to JSON"},
+		{`let xs = [1]; json_stringify([xs, xs])`, "[[1],[1]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+//Parsing what json_stringify writes gives back an equal value.
+func TestJSONRoundTrips(t *testing.T) {
+	inputs := []string{
+		`{"name": "shifu", "tags": ["a", "b"], "nested": {"n": -3, "f": 0.5, "ok": false}}`,
+		`[1, "two \" \\ \n", [], {}]`,
+	}
+
+	for _, input := range inputs {
+		value := testEval(input)
+		again := testEval("json_parse(json_stringify(" + input + "))")
+		if !object.Equals(value, again) {
+			t.Errorf("json didn't round trip for %s. got %s", input, again.Inspect())
+		}
+	}
+}
+
+func TestRegexBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`regex("a+b")`, `regex("a+b")`},
+		{`type(regex("a"))`, "REGEX"},
+		{`regex("a(")`, "ERROR: invalid regex: error parsing regexp: missing closing ): `a(`"},
+		{`regex(1)`, "ERROR: argument to 'regex' must be a STRING, got INTEGER"},
+		{`match(regex("b+"), "abbc")`, "{match: bb, start: 1, end: 3, groups: [], named: {}}"},
+		{`match("b+", "abbc")["match"]`, "bb"},
+		{`match(regex("x"), "abc")`, "null"},
+		{`match(regex("(?P<year>[0-9]+)-(?P<month>[0-9]+)"), "on 2024-05").named`, "{year: 2024, month: 05}"},
+		{`match(regex("(a)|(b)"), "b").groups`, "[null, b]"},
+		{`match(regex("é+"), "aéé").start`, "1"},
+		{`regex("[0-9]+").match("x12").match`, "12"},
+		{`map(find_all(regex("[0-9]+"), "a1b22c333"), func(m) { m.match })`, "[1, 22, 333]"},
+		{`find_all(regex("x"), "abc")`, "[]"},
+		{`len(regex("a").find_all("banana"))`, "3"},
+		{`match(1, "a")`, "ERROR: argument to 'match' must be a REGEX or a STRING, got INTEGER"},
+		{`match(regex("a"), 1)`, "ERROR: second argument to 'match' must be a STRING, got INTEGER"},
+		{`match("(", "a")`, "ERROR: invalid regex: error parsing regexp: missing closing ): `(`"},
+		{`replace("a1b22", regex("[0-9]+"), "#")`, "a#b#"},
+		{`replace("2024-05", regex("(?P<y>[0-9]+)-([0-9]+)"), "$2/${y}")`, "05/2024"},
+		{`replace("a1b22", regex("[0-9]+"), func(m) { str(len(m.match)) })`, "a1b2"},
+		{`replace("a1", regex("[0-9]"), func(m) { 1 })`, "ERROR: function passed to 'replace' must return a STRING, got INTEGER"},
+		{`replace("a1", regex("[0-9]"), 1)`, "ERROR: third argument to 'replace' must be a STRING or a FUNCTION, got INTEGER"},
+		{`replace("a.b", ".", "-")`, "a-b"},
+		{`split("a, b,c", regex(",\\s*"))`, "[a, b, c]"},
+		{`"a1b2c3".split(regex("[0-9]"))`, "[a, b, c, ]"},
+		{`regex("a") == regex("a")`, "true"},
+		{`regex("a") == regex("b")`, "false"},
+		{`repr(regex("a\\d"))`, `regex("a\\d")`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format("plain")`, "plain"},
+		{`format("%v and %s", [1, "a"], "b")`, "[1, a] and b"},
+		{`format("%q", "a\"b")`, `"a\"b"`},
+		{`format("%q", ["a", 1])`, `["a", 1]`},
+		{`format("%d|%5d|%-5d|%05d", 1, 2, 3, 4)`, "1|    2|3    |00004"},
+		{`format("%.2f %f", 3.14159, 2)`, "3.14 2.000000"},
+		{`format("%8.3f|", 1.5)`, "   1.500|"},
+		{`format("%e %g", 1500.0, 0.5)`, "1.500000e+03 0.5"},
+		{`format("%x %X %x", 255, 255, "hi")`, "ff FF 6869"},
+		{`format("%t", true)`, "true"},
+		{`format("%-4s|%4s|%.2s", "a", "b", "xyz")`, "a   |   b|xy"},
+		{`format("100%%")`, "100%"},
+		{`format("%d", "a")`, "ERROR: %d in the format string of 'format' needs an INTEGER, got STRING"},
+		{`format("%5.1f", "a")`, "ERROR: %5.1f in the format string of 'format' needs an INTEGER or a FLOAT, got STRING"},
+		{`format("%t", 1)`, "ERROR: %t in the format string of 'format' needs a BOOLEAN, got INTEGER"},
+		{`format("%d %d", 1)`, "ERROR: not enough arguments to 'format' for %d"},
+		{`format("%d", 1, 2)`, "ERROR: too many arguments to 'format', got=2, used=1"},
+		{`format("%z", 1)`, "ERROR: unknown verb %z in the format string of 'format'"},
+		{`format("50%")`, "ERROR: format string of 'format' ends in the middle of a verb: %"},
+		{`format(1)`, "ERROR: argument to 'format' must be a STRING, got INTEGER"},
+		{`format()`, "ERROR: wrong number of arguments. got=0, expected at least 1"},
+		{`printf("%d", "a")`, "ERROR: %d in the format string of 'printf' needs an INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+//Printing goes to the writer the interpreter was made with.
+func TestInterpreterOutput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`puts("a", 1)`, "a\n1\n"},
+		{`print("a", 1); print("b")`, "a 1b"},
+		{`printf("%s=%d\n", "x", 5)`, "x=5\n"},
+		{`let show = func(x) { puts(x) }; show([1]); map([2], puts)`, "[1]\n2\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if result := testEvalWith(NewInterpreter(&out), tt.input); isError(result) {
+			t.Fatalf("error evaluating %q: %s", tt.input, result.Inspect())
+		}
+		if out.String() != tt.expected {
+			t.Errorf("wrong output for %q. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte("one\ntwo\r\nthree"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(dir), "outside.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	interpreter := NewInterpreter(&bytes.Buffer{})
+	if err := interpreter.AllowFiles(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer interpreter.Close()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`read_file("data.txt")`, "one\ntwo\r\nthree"},
+		{`read_lines("data.txt")`, "[one, two, three]"},
+		{`let n = 0; each_line("data.txt", func(line) { n = n + len(line) }); n`, "11"},
+		{`each_line("data.txt", int)`, `ERROR: cannot convert "one" to INTEGER`},
+		{`write_file("sub/out.txt", "a"); append_file("sub/out.txt", "b"); read_file("sub/out.txt")`, "ab"},
+		{`append_file("new.txt", "x"); read_file("new.txt")`, "x"},
+		{`list_dir()`, "[data.txt, new.txt, sub]"},
+		{`list_dir("sub")`, "[out.txt]"},
+		{`[exists("data.txt"), exists("sub"), exists("missing.txt")]`, "[true, true, false]"},
+		{`read_file("missing.txt")`, "ERROR: read_file: openat missing.txt: no such file or directory"},
+		{`read_file("../outside.txt")`, "ERROR: read_file: openat ../outside.txt: path escapes from parent"},
+		{`write_file("../outside.txt", "x")`, "ERROR: write_file: openat ../outside.txt: path escapes from parent"},
+		{`exists("../outside.txt")`, "ERROR: exists: statat ../outside.txt: path escapes from parent"},
+		{`read_file(1)`, "ERROR: argument to 'read_file' must be a STRING, got INTEGER"},
+		{`each_line("data.txt", 1)`, "ERROR: second argument to 'each_line' must be a FUNCTION, got INTEGER"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		errObj, ok := evaluated.(*object.Error)
-		if !ok {
-			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
-			continue
+		evaluated := testEvalWith(interpreter, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
 		}
-		if errObj.Message != tt.expectedMessage {
-			t.Errorf("Wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+	}
+
+	if contents, _ := os.ReadFile(filepath.Join(filepath.Dir(dir), "outside.txt")); string(contents) != "secret" {
+		t.Errorf("file outside the root was changed, got %q", contents)
+	}
+}
+
+//Without AllowFiles scripts can't touch any file.
+func TestFilesNotAllowed(t *testing.T) {
+	inputs := []string{`read_file("x")`, `write_file("x", "y")`, `exists("x")`, `list_dir()`}
+
+	for _, input := range inputs {
+		for _, evaluated := range []object.Object{testEval(input), testEvalWith(NewInterpreter(&bytes.Buffer{}), input)} {
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || !strings.HasSuffix(errObj.Message, "needs file access, which the host hasn't allowed") {
+				t.Errorf("expected %q to be refused, got %s", input, evaluated.Inspect())
+			}
 		}
 	}
 }
 
-func TestLetStatements(t *testing.T) {
+func TestProcess(t *testing.T) {
+	exitCode := -1
+	interpreter := NewInterpreter(&bytes.Buffer{})
+	interpreter.AllowProcess(Process{
+		Args: []string{"in.txt", "-v"},
+		Env: func(name string) (string, bool) {
+			if name == "HOME" {
+				return "/home/shifu", true
+			}
+			return "", false
+		},
+		Stdin: strings.NewReader("first\r\nsecond\nrest\nof it"),
+		Exit:  func(code int) { exitCode = code },
+	})
+
 	tests := []struct {
 		input    string
-		expected int64
+		expected string
 	}{
-		{"let a = 5; a;", 5},
-		{"let a = 5 * 5; a;", 25},
-		{"let a = 5; let b = a; b;", 5},
-		{"let a = 5; let b = a; let c = a + b + 10; c;", 20},
+		{`args()`, "[in.txt, -v]"},
+		{`args()[1]`, "-v"},
+		{`env("HOME")`, "/home/shifu"},
+		{`env("MISSING")`, "null"},
+		{`read_line()`, "first"},
+		{`read_line()`, "second"},
+		{`read_all()`, "rest\nof it"},
+		{`read_line()`, "null"},
+		{`read_all()`, ""},
+		{`exit(3); 1`, "ERROR: exited with status 3"},
+		{`env(1)`, "ERROR: argument to 'env' must be a STRING, got INTEGER"},
+		{`exit("1")`, "ERROR: argument to 'exit' must be an INTEGER, got STRING"},
+		{`args(1)`, "ERROR: wrong number of arguments. got=1, expected=0"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testIntegerObject(t, evaluated, tt.expected)
+		evaluated := testEvalWith(interpreter, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+	if exitCode != 3 {
+		t.Errorf("exit wasn't passed to the host. got=%d", exitCode)
 	}
 }
 
-func TestFunctionObject(t *testing.T) {
-	input := "func(x) { x + 2;};"
+//Only the parts of the process the host shared can be used.
+func TestProcessNotAllowed(t *testing.T) {
+	interpreter := NewInterpreter(&bytes.Buffer{})
+	interpreter.AllowProcess(Process{Args: []string{}})
 
-	evaluated := testEval(input)
-	fn, ok := evaluated.(*object.Function)
-	if !ok {
-		t.Fatalf("object is not a Function. got=%T (%+v)", evaluated, evaluated)
+	if result := testEvalWith(interpreter, `args()`); result.Inspect() != "[]" {
+		t.Errorf("args should be allowed, got %s", result.Inspect())
 	}
-	if len(fn.Parameters) != 1 {
-		t.Fatalf("Function has wrong parameters. Parameters=%+v", fn.Parameters[0])
+	inputs := []string{`env("HOME")`, `exit(1)`, `read_line()`, `read_all()`}
+	for _, input := range inputs {
+		for _, evaluated := range []object.Object{testEval(input), testEvalWith(interpreter, input)} {
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || !strings.HasSuffix(errObj.Message, "needs access to the process, which the host hasn't allowed") {
+				t.Errorf("expected %q to be refused, got %s", input, evaluated.Inspect())
+			}
+		}
 	}
+}
 
-	expectedBody := "(x + 2)"
-	if fn.Body.String() != expectedBody {
-		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`abs(-3)`, "3"},
+		{`abs(2.5)`, "2.5"},
+		{`abs(-2.5)`, "2.5"},
+		{`abs(MIN_INT)`, "ERROR: integer overflow: abs(-9223372036854775808)"},
+		{`abs("a")`, "ERROR: argument to 'abs' must be an INTEGER or a FLOAT, got STRING"},
+		{`min(3, 1, 2)`, "1"},
+		{`max(3, 1, 2)`, "3"},
+		{`max([1, 2.5, 2])`, "2.5"},
+		{`min(["b", "a", "c"])`, "a"},
+		{`min(7)`, "7"},
+		{`max([])`, "ERROR: 'max' of an empty ARRAY"},
+		{`min()`, "ERROR: wrong number of arguments. got=0, expected at least 1"},
+		{`min(1, "a")`, "ERROR: cannot compare STRING and INTEGER"},
+		{`min(len)`, "ERROR: cannot compare BUILTIN and BUILTIN"},
+		{`pow(2, 10)`, "1024"},
+		{`pow(-3, 3)`, "-27"},
+		{`pow(2, -1)`, "0.5"},
+		{`pow(2.0, 3)`, "8.0"},
+		{`pow(4, 0.5)`, "2.0"},
+		{`pow(-1, 1000000000000)`, "1"},
+		{`pow(2, 63)`, "ERROR: integer overflow: pow(2, 63)"},
+		{`pow(10, 1000000)`, "ERROR: integer overflow: pow(10, 1000000)"},
+		{`pow(-8, 0.5)`, "ERROR: math domain error: pow(-8, 0.5)"},
+		{`sqrt(16)`, "4.0"},
+		{`sqrt(2.25)`, "1.5"},
+		{`sqrt(-1)`, "ERROR: math domain error: sqrt(-1)"},
+		{`floor(2.7)`, "2"},
+		{`floor(-2.5)`, "-3"},
+		{`ceil(2.1)`, "3"},
+		{`round(2.5)`, "3"},
+		{`round(-2.5)`, "-3"},
+		{`floor(4)`, "4"},
+		{`floor(INF)`, "ERROR: math domain error: floor(INF)"},
+		{`gcd(12, 18)`, "6"},
+		{`gcd(-4, 6, 10)`, "2"},
+		{`gcd(0, 5)`, "5"},
+		{`gcd(1.5, 2)`, "ERROR: argument to 'gcd' must be an INTEGER, got FLOAT"},
+		{`log(E)`, "1.0"},
+		{`log(8, 2)`, "3.0"},
+		{`log(0)`, "ERROR: math domain error: log(0)"},
+		{`log(8, 1)`, "ERROR: math domain error: log(8, 1)"},
+		{`exp(0)`, "1.0"},
+		{`sin(0)`, "0.0"},
+		{`cos(0)`, "1.0"},
+		{`tan(0)`, "0.0"},
+		{`round(atan(1) * 4 * 1000)`, "3142"},
+		{`asin(1) == PI / 2`, "true"},
+		{`acos(2)`, "ERROR: math domain error: acos(2)"},
+		{`sin("a")`, "ERROR: argument to 'sin' must be an INTEGER or a FLOAT, got STRING"},
+		{`MAX_INT`, "9223372036854775807"},
+		{`(PI > 3.14) && (PI < 3.15)`, "true"},
+		{`PI = 3`, "ERROR: cannot assign to constant PI"},
+		{`let PI = 3; PI = 4; PI`, "4"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
 	}
 }
 
-func TestFunctionApplication(t *testing.T) {
+func TestRandomBuiltins(t *testing.T) {
+	interpreter := NewInterpreter(&bytes.Buffer{})
+	interpreter.SeedRandom(42)
+
 	tests := []struct {
 		input    string
-		expected int64
+		expected string
 	}{
-		{"let iden = func(x) {x;}; iden(5);", 5},
-		{"let iden = func(x) {return x;};iden(5);", 5},
-		{"let double = func(x) { x * 2; };double(5);", 10},
-		{"let add = func(x, y) { x + y; };add(5, 5);", 10},
-		{"let add = func(x, y) { x + y; }; add(5 + 5, add(5,5));", 20},
-		{"func(x) {x;}(5)", 5},
+		{`let r = random(); !(r < 0) && (r < 1)`, "true"},
+		{`let xs = [random_int(1, 3), random_int(1, 3), random_int(1, 3)]; all(xs, func(x) { x in [1, 2, 3] })`, "true"},
+		{`random_int(5, 5)`, "5"},
+		{`is_int(random_int(MIN_INT, MAX_INT))`, "true"},
+		{`choice([7])`, "7"},
+		{`sort(shuffle([3, 1, 2]))`, "[1, 2, 3]"},
+		{`let xs = [1, 2, 3]; shuffle(xs); xs`, "[1, 2, 3]"},
+		{`shuffle([])`, "[]"},
+		{`random_int(3, 1)`, "ERROR: empty range for 'random_int': 3 to 1"},
+		{`random_int(1, "a")`, "ERROR: second argument to 'random_int' must be an INTEGER, got STRING"},
+		{`choice([])`, "ERROR: 'choice' of an empty ARRAY"},
+		{`shuffle("abc")`, "ERROR: argument to 'shuffle' must be an ARRAY, got STRING"},
+		{`seed(1.5)`, "ERROR: argument to 'seed' must be an INTEGER, got FLOAT"},
+		{`random(1)`, "ERROR: wrong number of arguments. got=1, expected=0"},
 	}
+
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		evaluated := testEvalWith(interpreter, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
 	}
 }
 
-func TestEnclosingEnvironments(t *testing.T) {
-	input := `
-let first = 10;
-let second = 10;
-let third = 10;
+//The same seed gives the same numbers, and seeding one interpreter leaves the numbers of another alone.
+func TestRandomIsSeededPerInterpreter(t *testing.T) {
+	draw := `[random(), random_int(1, 1000000), shuffle([1, 2, 3, 4, 5, 6, 7, 8]), choice(["a", "b", "c", "d"])]`
 
-let ourFunction = func(first) {
-  let second = 20;
+	first := NewInterpreter(&bytes.Buffer{})
+	first.SeedRandom(7)
+	second := NewInterpreter(&bytes.Buffer{})
+	testEvalWith(second, `seed(7)`)
 
-  first + second + third;
-};
+	a := testEvalWith(first, draw).Inspect()
+	b := testEvalWith(second, draw).Inspect()
+	if a != b {
+		t.Errorf("the same seed gave different numbers. got %s and %s", a, b)
+	}
 
-ourFunction(20) + first + second;`
+	testEvalWith(first, `seed(7)`)
+	testEvalWith(second, `random(); random();`)
+	again := testEvalWith(first, draw).Inspect()
+	if again != a {
+		t.Errorf("seeding again didn't repeat the numbers, or another interpreter changed them. got %s, expected %s", again, a)
+	}
 
-	testIntegerObject(t, testEval(input), 70)
+	//methods use the generator of the interpreter as well
+	testEvalWith(first, `seed(11)`)
+	testEvalWith(second, `seed(11)`)
+	asFunction := testEvalWith(first, `shuffle([1, 2, 3, 4, 5, 6, 7, 8])`).Inspect()
+	asMethod := testEvalWith(second, `[1, 2, 3, 4, 5, 6, 7, 8].shuffle()`).Inspect()
+	if asFunction != asMethod {
+		t.Errorf("the shuffle method didn't use the generator of the interpreter. got %s, expected %s", asMethod, asFunction)
+	}
 }
 
-func TestClosures(t *testing.T) {
-	input := `
-	let newAdder = func(x){
-		func(y) { x + y };
-	};
-	
-	let addTwo = newAdder(2);
-	addTwo(2);
-	`
-	testIntegerObject(t, testEval(input), 4)
+//run with -race, Eval without an interpreter shares one generator between goroutines
+func TestRandomWithoutInterpreterIsSafeForConcurrentEval(t *testing.T) {
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if evaluated := testEval(`seed(3); random_int(1, 6) + random()`); evaluated.Type() != object.FLOAT_OBJ {
+					t.Errorf("wrong result of drawing numbers. got=%s", evaluated.Inspect())
+				}
+			}
+		}()
+	}
+	wg.Wait()
 }
 
-func TestStringLiteral(t *testing.T) {
-	input := `"Inner Peace!"`
+func TestTimeBuiltins(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	interpreter := NewInterpreter(&bytes.Buffer{})
+	interpreter.SetClock(func() time.Time { return clock })
 
-	evaluated := testEval(input)
-	str, ok := evaluated.(*object.String)
-	if !ok {
-		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`now()`, "2024-05-01T10:30:00Z"},
+		{`type(now())`, "TIME"},
+		{`date(2024, 2, 29)`, "2024-02-29T00:00:00Z"},
+		{`date(2024, 1, 2, 3, 4, 5)`, "2024-01-02T03:04:05Z"},
+		{`date(2024, 1)`, "ERROR: wrong number of arguments. got=2, expected=3 to 6"},
+		{`parse_time("2024-05-01T12:00:00+02:00")`, "2024-05-01T12:00:00+02:00"},
+		{`parse_time("01/05/2024", "02/01/2006")`, "2024-05-01T00:00:00Z"},
+		{`parse_time("yesterday")`, `ERROR: cannot parse time: parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`},
+		{`format_time(now(), "2006-01-02 15:04")`, "2024-05-01 10:30"},
+		{`now().format_time("Jan 2")`, "May 1"},
+		{`[now().year(), now().month(), now().day(), now().hour(), now().minute(), now().second(), now().weekday()]`, "[2024, 5, 1, 10, 30, 0, Wednesday]"},
+		{`utc(parse_time("2024-05-01T12:00:00+02:00"))`, "2024-05-01T10:00:00Z"},
+		{`unix(date(1970, 1, 2))`, "86400"},
+		{`from_unix(86400)`, "1970-01-02T00:00:00Z"},
+		{`from_unix(1.5)`, "1970-01-01T00:00:01.5Z"},
+		{`duration("1h30m")`, "1h30m0s"},
+		{`duration(90)`, "1m30s"},
+		{`duration(0.25)`, "250ms"},
+		{`duration("soon")`, `ERROR: cannot parse duration: time: invalid duration "soon"`},
+		{`seconds(duration("1m30s"))`, "90.0"},
+		{`duration("1m").seconds()`, "60.0"},
+		{`now() - date(2024, 5, 1)`, "10h30m0s"},
+		{`now() + duration("1h")`, "2024-05-01T11:30:00Z"},
+		{`duration("24h") + now()`, "2024-05-02T10:30:00Z"},
+		{`now() - duration("30m")`, "2024-05-01T10:00:00Z"},
+		{`duration("1h") + duration("30m")`, "1h30m0s"},
+		{`duration("1h") - duration("2h")`, "-1h0m0s"},
+		{`-duration("1s")`, "-1s"},
+		{`duration("1h") * 3`, "3h0m0s"},
+		{`2 * duration("1m")`, "2m0s"},
+		{`duration("1h") * 0.5`, "30m0s"},
+		{`duration("1h") / 4`, "15m0s"},
+		{`duration("1h") / duration("15m")`, "4.0"},
+		{`duration("1h") / 0`, "ERROR: division by zero: 1h0m0s / 0"},
+		{`duration("1h") * MAX_INT`, "ERROR: duration out of range: 1h0m0s * 9223372036854775807"},
+		{`now() > date(2024, 1, 1)`, "true"},
+		{`now() < date(2024, 1, 1)`, "false"},
+		{`parse_time("2024-05-01T12:30:00+02:00") == now()`, "true"},
+		{`duration("60s") == duration("1m")`, "true"},
+		{`duration("1s") < duration("1m")`, "true"},
+		{`now() == 1`, "false"},
+		{`now() + now()`, "ERROR: unknown operator: TIME + TIME"},
+		{`now() * 2`, "ERROR: type mismatch: TIME * INTEGER"},
+		{`sort([now(), date(2020, 1, 1)])[0]`, "2020-01-01T00:00:00Z"},
+		{`max([duration("1s"), duration("1h")])`, "1h0m0s"},
+		{`repr([now(), duration("1m")])`, `[parse_time("2024-05-01T10:30:00Z"), duration("1m0s")]`},
+		{`json_stringify({"at": now()})`, `{"at":"2024-05-01T10:30:00Z"}`},
+		{`is_time(now())`, "true"},
+		{`is_duration(duration("1s"))`, "true"},
+		{`year("2024")`, "ERROR: argument to 'year' must be a TIME, got STRING"},
 	}
-	if str.Value != "Inner Peace!" {
-		t.Errorf("String has wrong value. got=%q", str.Value)
+
+	for _, tt := range tests {
+		evaluated := testEvalWith(interpreter, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
 	}
-}
 
-func TestStringConcatenation(t *testing.T) {
-	input := ` "Inner " + "" + " Peace!" `
+	clock = clock.Add(time.Minute)
+	if result := testEvalWith(interpreter, `now()`).Inspect(); result != "2024-05-01T10:31:00Z" {
+		t.Errorf("now didn't ask the clock again, got %s", result)
+	}
+}
 
-	evaluated := testEval(input)
-	str, ok := evaluated.(*object.String)
-	if !ok {
-		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+func TestEncodingBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`bytes("hi")`, `hex_decode("6869")`},
+		{`bytes([104, 105]) == bytes("hi")`, "true"},
+		{`bytes([256])`, "ERROR: cannot convert 256 to a byte"},
+		{`bytes(1)`, "ERROR: argument to 'bytes' must be a STRING or an ARRAY, got INTEGER"},
+		{`len(bytes("é"))`, "2"},
+		{`str(bytes("héllo"))`, "héllo"},
+		{`str(hex_decode("ff"))`, "ERROR: cannot convert BYTES that aren't valid UTF-8 to STRING"},
+		{`type(bytes(""))`, "BYTES"},
+		{`base64_encode("hello?>")`, "aGVsbG8/Pg=="},
+		{`base64url_encode("hello?>")`, "aGVsbG8_Pg"},
+		{`str(base64_decode("aGVsbG8/Pg=="))`, "hello?>"},
+		{`str(base64url_decode("aGVsbG8_Pg"))`, "hello?>"},
+		{`str(base64url_decode("aGVsbG8_Pg=="))`, "hello?>"},
+		{`base64_decode("!!")`, "ERROR: base64_decode: illegal base64 data at input byte 0"},
+		{`hex_encode("hi")`, "6869"},
+		{`hex_decode("6869").hex_encode()`, "6869"},
+		{`hex_decode("zz")`, "ERROR: hex_decode: encoding/hex: invalid byte: U+007A 'z'"},
+		{`hex_encode(1)`, "ERROR: argument to 'hex_encode' must be BYTES or a STRING, got INTEGER"},
+		{`url_encode("a b&c=d/é")`, "a+b%26c%3Dd%2F%C3%A9"},
+		{`url_decode("a+b%26c%3Dd%2F%C3%A9")`, "a b&c=d/é"},
+		{`url_decode("%zz")`, `ERROR: url_decode: invalid URL escape "%zz"`},
+		{`query_encode({"q": "a b", "page": 2, "tag": ["x", "y"]})`, "q=a+b&page=2&tag=x&tag=y"},
+		{`query_encode({"f": func() { 1 }})`, "ERROR: cannot put FUNCTION in a URL query"},
+		{`query_decode("q=a+b&page=2&tag=x&tag=y&empty")`, "{q: a b, page: 2, tag: [x, y], empty: }"},
+		{`query_decode("a=%zz")`, `ERROR: query_decode: invalid escape in "a=%zz"`},
+		{`hex_encode(md5(""))`, "d41d8cd98f00b204e9800998ecf8427e"},
+		{`hex_encode(sha1("abc"))`, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{`hex_encode(sha256("abc"))`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`sha512("abc").hex_encode()`, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{`sha256(bytes("abc")) == sha256("abc")`, "true"},
+		{`crc32("The quick brown fox jumps over the lazy dog")`, "1095738169"},
+		{`hex_encode(hmac("sha256", "key", "The quick brown fox jumps over the lazy dog"))`, "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{`hex_encode(hmac("md5", "key", "The quick brown fox jumps over the lazy dog"))`, "80070713463e7749b90c2dc24911e275"},
+		{`hmac("sha3", "key", "message")`, `ERROR: unknown digest for 'hmac': "sha3", expected md5, sha1, sha256 or sha512`},
+		{`hmac("sha256", 1, "message")`, "ERROR: second argument to 'hmac' must be BYTES or a STRING, got INTEGER"},
+		{`repr([bytes("a")])`, `[hex_decode("61")]`},
+		{`is_bytes(bytes("a"))`, "true"},
 	}
-	if str.Value != "Inner  Peace!" {
-		t.Errorf("String has wrong value. got=%q", str.Value)
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
 	}
 }
 
-func TestBuiltinFunctions(t *testing.T) {
+func TestInOperator(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected interface{}
+		expected string
 	}{
-		{`len("")`, 0},
-		{`len("four")`, 4},
-		{`len("hello world")`, 11},
-		{`len(1)`, "argument to `len` not supported, got INTEGER"},
-		{`len("one","two")`, "wrong number of arguments. got=2, expected=1"},
-		{`len([1, 2, 3])`, 3},
-		{`len([])`, 0},
-		{`first([1, 2, 3])`, 1},
-		{`first([])`, "an array has no elements!"},
-		{`first(1)`, "argument to 'first' must be an ARRAY, got INTEGER"},
-		{`last([1, 2, 3])`, 3},
-		{`last([])`, "an array has no elements!"},
-		{`last(1)`, "argument to 'last' must be an ARRAY, got INTEGER"},
-		{`push([], 1)`, []int{1}},
-		{`push(1, 1)`, "argument to 'push' must be an ARRAY, got INTEGER"},
-		{`pop([1, 2, 3], 1)`, []int{1, 3}},
-		{`pop([1, 2, 3], 2)`, []int{1, 2}},
-		{`pop(1, 1)`, "argument to 'pop' must be an ARRAY, got INTEGER"},
-		{`pop([], [])`, "second argument to 'pop' must be an INTEGER, got ARRAY"},
-		{`pop([1, 2, 3], -1)`, "Index to pop from is out of bounds!"},
-		{`pop([1, 2, 3], 3)`, "Index to pop from is out of bounds!"},
-		{`pop([1, 2, 3], 6)`, "Index to pop from is out of bounds!"},
+		{`2 in [1, 2, 3]`, "true"},
+		{`4 in [1, 2, 3]`, "false"},
+		{`[1] in [[1], [2]]`, "true"},
+		{`"a" in {"a": 1}`, "true"},
+		{`1 in {"a": 1}`, "false"},
+		{`"ell" in "hello"`, "true"},
+		{`"z" in "hello"`, "false"},
+		{`1 + 1 in [2] == true`, "true"},
+		{`!(1 in [])`, "true"},
+		{`[1] in {"a": 1}`, "ERROR: unusable as hash key: ARRAY"},
+		{`1 in "hello"`, "ERROR: unknown operator: INTEGER in STRING"},
+		{`1 in 2`, "ERROR: unknown operator: INTEGER in INTEGER"},
 	}
 
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-
-		switch expected := tt.expected.(type) {
-		case int:
-			testIntegerObject(t, evaluated, int64(expected))
-		case string:
-			errorObj, ok := evaluated.(*object.Error)
-			if !ok {
-				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
-				continue
-			}
-			if errorObj.Message != expected {
-				t.Errorf("wrong error message. expected=%q, got=%q", expected, errorObj.Message)
-			}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
 		}
 	}
 }
@@ -441,7 +1913,15 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
-			"Array Index out of bounds: [-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
+			"Array Index out of bounds: [-4]",
 		},
 	}
 
@@ -463,6 +1943,74 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[-1]`, "o"},
+		{`let s = "abc"; s[0] + s[2]`, "ac"},
+		{`"abc"[3]`, "ERROR: String Index out of bounds: [3]"},
+		{`"abc"[-4]`, "ERROR: String Index out of bounds: [-4]"},
+		{`""[0]`, "ERROR: String Index out of bounds: [0]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[0, 1, 2, 3, 4][1:3]", "[1, 2]"},
+		{"[0, 1, 2, 3, 4][:2]", "[0, 1]"},
+		{"[0, 1, 2, 3, 4][3:]", "[3, 4]"},
+		{"[0, 1, 2, 3, 4][:]", "[0, 1, 2, 3, 4]"},
+		{"[0, 1, 2, 3, 4][:-1]", "[0, 1, 2, 3]"},
+		{"[0, 1, 2, 3, 4][-2:]", "[3, 4]"},
+		{"[0, 1, 2, 3, 4][::2]", "[0, 2, 4]"},
+		{"[0, 1, 2, 3, 4][1::2]", "[1, 3]"},
+		{"[0, 1, 2, 3, 4][::-1]", "[4, 3, 2, 1, 0]"},
+		{"[0, 1, 2, 3, 4][3:0:-1]", "[3, 2, 1]"},
+		{"[0, 1, 2, 3, 4][-1:-4:-2]", "[4, 2]"},
+		// bounds out of range are clamped, a slice is never out of bounds
+		{"[0, 1, 2][1:100]", "[1, 2]"},
+		{"[0, 1, 2][-100:2]", "[0, 1]"},
+		{"[0, 1, 2][5:]", "[]"},
+		{"[0, 1, 2][2:1]", "[]"},
+		{"[0, 1, 2][100:-100:-1]", "[2, 1, 0]"},
+		{"[][:]", "[]"},
+		{"[][::-1]", "[]"},
+		{"let i = 1; [0, 1, 2, 3][i:i + 2]", "[1, 2]"},
+		{"let xs = [1, 2, 3]; let ys = xs[:]; ys[0] = 9; xs", "[1, 2, 3]"},
+		{`"héllo"[1:3]`, "él"},
+		{`"héllo"[::-1]`, "olléh"},
+		{`"hello"[2:]`, "llo"},
+		{`"hello"[-3:-1]`, "ll"},
+		{"[1, 2][::0]", "ERROR: slice step cannot be zero"},
+		{"[1, 2, 3][1::9223372036854775807]", "[2]"},
+		{"[1, 2, 3][1::-9223372036854775807]", "[2]"},
+		{"[1, 2, 3][::MIN_INT]", "[3]"},
+		{`"abc"[::9223372036854775807]`, "a"},
+		{`[1, 2]["a":]`, "ERROR: slice indices must be INTEGER, got STRING"},
+		{`{"a": 1}[0:1]`, "ERROR: slice operator not supported: HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 	{
@@ -480,26 +2028,130 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong number of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong number of pairs. got=%d", result.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for i, tt := range expected {
+		pair, ok := result.Get(tt.key)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
 		}
 
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, pair.Value, tt.value)
+
+		// pairs come out in the order they were written in
+		if result.Pairs()[i].Key.Inspect() != tt.key.Inspect() {
+			t.Errorf("pair %d has wrong key. expected=%s, got=%s", i, tt.key.Inspect(), result.Pairs()[i].Key.Inspect())
+		}
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, "c": 3}`, "{b: 1, a: 2, c: 3}"},
+		{`{3: "x", 1: "y", 2: "z"}`, "{3: x, 1: y, 2: z}"},
+		{`let h = {"b": 1, "a": 2}; h["c"] = 3; h["b"] = 4; h`, "{b: 4, a: 2, c: 3}"},
+		{`{"a": 1, "b": 2, "a": 3}`, "{a: 3, b: 2}"},
+		{`let keys = []; for (k in {"z": 1, "y": 2, "x": 3}) { keys = push(keys, k); }; keys`, "[z, y, x]"},
+		{`let order = []; let key = func(k) { order = push(order, k); k }; {key("b"): 1, key("a"): 2}; order`, "[b, a]"},
+	}
+
+	for _, tt := range tests {
+		// run every program a few times, with map ordering any difference would show up quickly
+		for i := 0; i < 10; i++ {
+			evaluated := testEval(tt.input)
+			if evaluated.Inspect() != tt.expected {
+				t.Fatalf("wrong order for %q. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[[1, [2]], \"a\"] == [[1, [2]], \"a\"]", true},
+		{"[[1, [2]]] == [[1, [3]]]", false},
+		{"[] == []", true},
+		{`{"a": 1} == {"a": 1}`, true},
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": [1, {"b": 2}]} == {"a": [1, {"b": 2}]}`, true},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{"[1] == freeze([1])", true},
+		{`1 == "1"`, false},
+		{"[1] == 1", false},
+		{"let f = func() { 1 }; f == f", true},
+		{"func() { 1 } == func() { 1 }", false},
+		{"let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b", true},
+		{"let a = [1, 2]; a[0] = a; let b = [1, 3]; b[0] = b; a == b", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestTupleKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let h = {freeze([1, 2]): 5}; h[freeze([1, 2])]`, 5},
+		{`let h = {freeze([1, 2]): 5}; h[freeze([2, 1])]`, nil},
+		{`let h = {freeze([1, [2, "a"]]): 5}; h[freeze([1, [2, "a"]])]`, 5},
+		{`let h = {}; h[freeze([0, 0])] = 1; h[freeze([0, 0])] = 2; h[freeze([0, 0])]`, 2},
+		{`let h = {freeze([]): 7}; h[freeze([])]`, 7},
+		{`let h = {freeze([1]): 1, 1: 2}; h[1]`, 2},
+		{`{[1, 2]: 5}`, "unusable as hash key: ARRAY"},
+		{`let h = {}; h[[1]] = 5;`, "unusable as hash key: ARRAY"},
+		{`{freeze([1]): 5}[[1]]`, "unusable as hash key: ARRAY"},
+		{`{freeze([func() { 1 }]): 5}`, "unusable as hash key: ARRAY"},
+		{`{freeze([{"a": 1}]): 5}`, "unusable as hash key: ARRAY"},
+		{`let a = [1]; a[0] = a; freeze(a); {a: 1}`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
 	}
 }
 
@@ -549,15 +2201,48 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
+//Pushing one element at a time should take linear time, not quadratic.
+func BenchmarkPush(b *testing.B) {
+	input := `
+	let xs = [];
+	let i = 0;
+	while (i < 10000) { xs = push(xs, i); i = i + 1; }
+	len(xs)`
+
+	for i := 0; i < b.N; i++ {
+		testEval(input)
+	}
+}
+
 //All Helper functions
+
+//Programs are resolved before being evaluated, like the REPL does, so local variables live in slots.
+//Resolver errors are ignored so that the errors the evaluator reports itself can still be tested.
 func testEval(input string) object.Object {
-	l := lexer.New(input)
-	p := parser.New(l)
-	program := p.ParseProgram()
+	program := testParse(input)
+	resolver.New(BuiltinNames()...).Resolve(program)
 	env := object.NewEnvironment()
 	return Eval(program, env)
 }
 
+func testEvalWith(interpreter *Interpreter, input string) object.Object {
+	program := testParse(input)
+	resolver.New(BuiltinNames()...).Resolve(program)
+	return interpreter.Eval(program)
+}
+
+//Evaluates without resolving first, every variable is looked up by name.
+func testEvalUnresolved(input string) object.Object {
+	env := object.NewEnvironment()
+	return Eval(testParse(input), env)
+}
+
+func testParse(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
+
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	result, ok := obj.(*object.Integer)
 	if !ok {
@@ -0,0 +1,202 @@
+package evaluator
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//The file builtins are there for the resolver to know of, but without a root directory given by the host
+//every one of them is an error. An interpreter allowed files binds working ones in its environment.
+func init() {
+	for name, builtin := range fileBuiltins(nil) {
+		builtins[name] = builtin
+	}
+}
+
+//AllowFiles lets the scripts of the interpreter read and write files under dir, and nowhere else.
+//Paths are taken relative to dir, a path leading out of it, through '..' or a symbolic link, is an error.
+func (i *Interpreter) AllowFiles(dir string) error {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return err
+	}
+	if i.root != nil {
+		i.root.Close()
+	}
+	i.root = root
+	for name, builtin := range fileBuiltins(root) {
+		i.env.Set(name, builtin)
+	}
+	return nil
+}
+
+/*
+fileBuiltins makes the builtins working with the files under root.
+	read_file(path)             the contents of the file as a string
+	read_lines(path)            the lines of the file as an array, without the line endings
+	each_line(path, fn)         calls fn with every line, without reading the whole file first
+	write_file(path, contents)  replaces the file with contents, creating it if needed
+	append_file(path, contents) adds contents to the end of the file, creating it if needed
+	list_dir([path])            the sorted names in the directory, the root when no path is given
+	exists(path)                tells if there is a file or directory at path
+*/
+func fileBuiltins(root *os.Root) map[string]*object.Builtin {
+	fns := map[string]object.BuiltinFunction{
+		"read_file": func(args ...object.Object) object.Object {
+			values, err := stringArguments("read_file", args, 1, 1)
+			if err != nil {
+				return err
+			}
+			contents, readErr := root.ReadFile(values[0])
+			if readErr != nil {
+				return fileError("read_file", readErr)
+			}
+			return &object.String{Value: string(contents)}
+		},
+		"read_lines": func(args ...object.Object) object.Object {
+			values, err := stringArguments("read_lines", args, 1, 1)
+			if err != nil {
+				return err
+			}
+			lines := []object.Object{}
+			readErr := eachLine(root, "read_lines", values[0], func(line string) *object.Error {
+				lines = append(lines, &object.String{Value: line})
+				return nil
+			})
+			if readErr != nil {
+				return readErr
+			}
+			return &object.Array{Elements: lines}
+		},
+		"each_line": func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return wrongNumberOfArguments(len(args), 2, 2)
+			}
+			path, ok := args[0].(*object.String)
+			if !ok {
+				return argumentError("each_line", 0, "a STRING", args[0])
+			}
+			if !isCallable(args[1]) {
+				return argumentError("each_line", 1, "a FUNCTION", args[1])
+			}
+			readErr := eachLine(root, "each_line", path.Value, func(line string) *object.Error {
+				if result := applyFunction(args[1], []object.Object{&object.String{Value: line}}); isError(result) {
+					return result.(*object.Error)
+				}
+				return nil
+			})
+			if readErr != nil {
+				return readErr
+			}
+			return NULL
+		},
+		"write_file": func(args ...object.Object) object.Object {
+			values, err := stringArguments("write_file", args, 2, 2)
+			if err != nil {
+				return err
+			}
+			if writeErr := root.WriteFile(values[0], []byte(values[1]), 0644); writeErr != nil {
+				return fileError("write_file", writeErr)
+			}
+			return NULL
+		},
+		"append_file": func(args ...object.Object) object.Object {
+			values, err := stringArguments("append_file", args, 2, 2)
+			if err != nil {
+				return err
+			}
+			file, openErr := root.OpenFile(values[0], os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if openErr != nil {
+				return fileError("append_file", openErr)
+			}
+			_, writeErr := file.WriteString(values[1])
+			if closeErr := file.Close(); writeErr == nil {
+				writeErr = closeErr
+			}
+			if writeErr != nil {
+				return fileError("append_file", writeErr)
+			}
+			return NULL
+		},
+		"list_dir": func(args ...object.Object) object.Object {
+			values, err := stringArguments("list_dir", args, 0, 1)
+			if err != nil {
+				return err
+			}
+			path := "."
+			if len(values) == 1 {
+				path = values[0]
+			}
+			entries, readErr := fs.ReadDir(root.FS(), path)
+			if readErr != nil {
+				return fileError("list_dir", readErr)
+			}
+			names := make([]string, len(entries))
+			for i, entry := range entries {
+				names[i] = entry.Name()
+			}
+			sort.Strings(names)
+			return stringArray(names)
+		},
+		"exists": func(args ...object.Object) object.Object {
+			values, err := stringArguments("exists", args, 1, 1)
+			if err != nil {
+				return err
+			}
+			_, statErr := root.Stat(values[0])
+			if errors.Is(statErr, fs.ErrNotExist) {
+				return FALSE
+			}
+			if statErr != nil {
+				return fileError("exists", statErr)
+			}
+			return TRUE
+		},
+	}
+
+	fileBuiltins := make(map[string]*object.Builtin, len(fns))
+	for name, fn := range fns {
+		if root == nil {
+			fn = filesNotAllowed(name)
+		}
+		fileBuiltins[name] = &object.Builtin{Fn: fn}
+	}
+	return fileBuiltins
+}
+
+//eachLine calls fn with every line of the file, stopping at the first error fn returns.
+func eachLine(root *os.Root, name string, path string, fn func(line string) *object.Error) *object.Error {
+	file, err := root.Open(path)
+	if err != nil {
+		return fileError(name, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		if err := fn(strings.TrimSuffix(scanner.Text(), "\r")); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fileError(name, err)
+	}
+	return nil
+}
+
+func fileError(name string, err error) *object.Error {
+	return newError("%s: %s", name, err)
+}
+
+func filesNotAllowed(name string) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		return newError("'%s' needs file access, which the host hasn't allowed", name)
+	}
+}
@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+func init() {
+	builtins["format"] = &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		return formatBuiltin("format", args)
+	}}
+}
+
+/*
+formatBuiltin fills in the verbs of the format string with the arguments after it, the way Go's
+fmt does. A verb can have the flags '-', '+', '#', ' ' and '0', a width and a precision, like '%-8.2f'.
+	%v, %s  the value the way puts prints it
+	%q      the value the way repr writes it, strings are quoted
+	%d      an integer
+	%f, %e, %g  a float, integers are taken as floats
+	%x, %X  an integer or a string in hex
+	%t      a boolean
+	%%      a percent sign
+Unlike Go a verb without an argument, an argument without a verb or an argument of the wrong
+type is an error, name is the builtin the error is reported for.
+*/
+func formatBuiltin(name string, args []object.Object) object.Object {
+	if len(args) < 1 {
+		return newError("wrong number of arguments. got=%d, expected at least 1", len(args))
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return argumentError(name, 0, "a STRING", args[0])
+	}
+
+	format := str.Value
+	values := args[1:]
+	used := 0
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+# 0", format[i]) >= 0 {
+			i++
+		}
+		for i < len(format) && isDigit(format[i]) {
+			i++
+		}
+		if i < len(format) && format[i] == '.' {
+			i++
+			for i < len(format) && isDigit(format[i]) {
+				i++
+			}
+		}
+		if i == len(format) {
+			return newError("format string of '%s' ends in the middle of a verb: %s", name, format[start:])
+		}
+		spec, verb := format[start:i], format[i]
+
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if used == len(values) {
+			return newError("not enough arguments to '%s' for %s%c", name, spec, verb)
+		}
+		formatted, err := formatVerb(name, spec, verb, values[used])
+		if err != nil {
+			return err
+		}
+		out.WriteString(formatted)
+		used++
+	}
+
+	if used < len(values) {
+		return newError("too many arguments to '%s', got=%d, used=%d", name, len(values), used)
+	}
+	return &object.String{Value: out.String()}
+}
+
+//formatVerb formats a single value, spec is the verb up to the letter with its flags, width and precision.
+func formatVerb(name string, spec string, verb byte, value object.Object) (string, *object.Error) {
+	switch verb {
+	case 'v', 's':
+		return fmt.Sprintf(spec+"s", value.Inspect()), nil
+	case 'q':
+		return fmt.Sprintf(spec+"s", repr(value)), nil
+	case 'd':
+		if value, ok := value.(*object.Integer); ok {
+			return fmt.Sprintf(spec+"d", value.Value), nil
+		}
+		return "", verbError(name, spec, verb, "an INTEGER", value)
+	case 'f', 'e', 'g':
+		if isNumber(value) {
+			return fmt.Sprintf(spec+string(verb), toFloat(value)), nil
+		}
+		return "", verbError(name, spec, verb, "an INTEGER or a FLOAT", value)
+	case 'x', 'X':
+		switch value := value.(type) {
+		case *object.Integer:
+			return fmt.Sprintf(spec+string(verb), value.Value), nil
+		case *object.String:
+			return fmt.Sprintf(spec+string(verb), value.Value), nil
+		}
+		return "", verbError(name, spec, verb, "an INTEGER or a STRING", value)
+	case 't':
+		if value, ok := value.(*object.Boolean); ok {
+			return fmt.Sprintf(spec+"t", value.Value), nil
+		}
+		return "", verbError(name, spec, verb, "a BOOLEAN", value)
+	default:
+		return "", newError("unknown verb %s%c in the format string of '%s'", spec, verb, name)
+	}
+}
+
+func verbError(name string, spec string, verb byte, expected string, got object.Object) *object.Error {
+	return newError("%s%c in the format string of '%s' needs %s, got %s", spec, verb, name, expected, got.Type())
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
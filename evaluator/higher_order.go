@@ -0,0 +1,295 @@
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+/*
+The builtins in this file take a function as an argument and call it,
+either a function written in shifu or another builtin. Calling a function
+goes through applyFunction, which through Eval leads back to the builtins
+map again, so they can't be listed in the map itself and are added to it
+when the package is initialized instead.
+*/
+func init() {
+	builtins["map"] = &object.Builtin{Fn: mapBuiltin}
+	builtins["filter"] = &object.Builtin{Fn: filterBuiltin}
+	builtins["reduce"] = &object.Builtin{Fn: reduceBuiltin}
+	builtins["find"] = &object.Builtin{Fn: findBuiltin}
+	builtins["any"] = &object.Builtin{Fn: anyBuiltin}
+	builtins["all"] = &object.Builtin{Fn: allBuiltin}
+	builtins["sort"] = &object.Builtin{Fn: sortBuiltin}
+}
+
+//arrayAndCallback checks the arguments of the builtins that take an array and a function to call for its elements.
+func arrayAndCallback(name string, args []object.Object) (*object.Array, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments. got=%d, expected=2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, nil, argumentError(name, 0, "an ARRAY", args[0])
+	}
+	if !isCallable(args[1]) {
+		return nil, nil, argumentError(name, 1, "a FUNCTION", args[1])
+	}
+	return arr, args[1], nil
+}
+
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Builtin:
+		return true
+	}
+	return false
+}
+
+func mapBuiltin(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndCallback("map", args)
+	if err != nil {
+		return err
+	}
+	elements := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		result := applyFunction(fn, []object.Object{el})
+		if isError(result) {
+			return result
+		}
+		elements[i] = result
+	}
+	return &object.Array{Elements: elements}
+}
+
+func filterBuiltin(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndCallback("filter", args)
+	if err != nil {
+		return err
+	}
+	elements := []object.Object{}
+	for _, el := range arr.Elements {
+		result := applyFunction(fn, []object.Object{el})
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) {
+			elements = append(elements, el)
+		}
+	}
+	return &object.Array{Elements: elements}
+}
+
+//reduce calls the function with the result so far and the next element. Without an initial value
+//the first element is the start and the function is called for the rest of them.
+func reduceBuiltin(args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, expected=2 or 3", len(args))
+	}
+	arr, fn, err := arrayAndCallback("reduce", args[:2])
+	if err != nil {
+		return err
+	}
+
+	elements := arr.Elements
+	var acc object.Object
+	if len(args) == 3 {
+		acc = args[2]
+	} else {
+		if len(elements) == 0 {
+			return newError("reduce of an empty array with no initial value")
+		}
+		acc, elements = elements[0], elements[1:]
+	}
+
+	for _, el := range elements {
+		acc = applyFunction(fn, []object.Object{acc, el})
+		if isError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+//find returns the first element the function is truthy for, or null when there is none.
+func findBuiltin(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndCallback("find", args)
+	if err != nil {
+		return err
+	}
+	for _, el := range arr.Elements {
+		result := applyFunction(fn, []object.Object{el})
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) {
+			return el
+		}
+	}
+	return NULL
+}
+
+func anyBuiltin(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndCallback("any", args)
+	if err != nil {
+		return err
+	}
+	for _, el := range arr.Elements {
+		result := applyFunction(fn, []object.Object{el})
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) {
+			return TRUE
+		}
+	}
+	return FALSE
+}
+
+func allBuiltin(args ...object.Object) object.Object {
+	arr, fn, err := arrayAndCallback("all", args)
+	if err != nil {
+		return err
+	}
+	for _, el := range arr.Elements {
+		result := applyFunction(fn, []object.Object{el})
+		if isError(result) {
+			return result
+		}
+		if !isTruthy(result) {
+			return FALSE
+		}
+	}
+	return TRUE
+}
+
+//sort returns a new sorted array, elements that are equal keep the order they had.
+//Without a function integers and strings are sorted ascending. A function of two parameters is a comparator
+//returning a negative integer when its first argument goes first, zero when they are equal and a positive
+//integer otherwise. Any other function, builtins included, is a key function: elements are sorted by what it returns for them.
+func sortBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, expected=1 or 2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return argumentError("sort", 0, "an ARRAY", args[0])
+	}
+
+	elements := make([]object.Object, len(arr.Elements))
+	copy(elements, arr.Elements)
+
+	if len(args) == 1 {
+		return sortBy(elements, elements, compareObjects)
+	}
+
+	fn := args[1]
+	if !isCallable(fn) {
+		return argumentError("sort", 1, "a FUNCTION", fn)
+	}
+
+	if f, ok := fn.(*object.Function); ok && len(f.Parameters) == 2 {
+		return sortBy(elements, elements, func(a, b object.Object) (int, *object.Error) {
+			result := applyFunction(fn, []object.Object{a, b})
+			if err, ok := result.(*object.Error); ok {
+				return 0, err
+			}
+			order, ok := result.(*object.Integer)
+			if !ok {
+				return 0, newError("comparator passed to 'sort' must return an INTEGER, got %s", result.Type())
+			}
+			return int(order.Value), nil
+		})
+	}
+
+	// the key of every element is worked out once up front
+	keys := make([]object.Object, len(elements))
+	for i, el := range elements {
+		keys[i] = applyFunction(fn, []object.Object{el})
+		if isError(keys[i]) {
+			return keys[i]
+		}
+	}
+	return sortBy(elements, keys, compareObjects)
+}
+
+//sortBy sorts elements stably by comparing the keys at the same positions, elements can be its own keys.
+//The first error the comparison returns ends the sort and is returned instead of the array.
+func sortBy(elements, keys []object.Object, compare func(a, b object.Object) (int, *object.Error)) object.Object {
+	order := make([]int, len(elements))
+	for i := range order {
+		order[i] = i
+	}
+
+	var err *object.Error
+	sort.SliceStable(order, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		result, e := compare(keys[order[i]], keys[order[j]])
+		if e != nil {
+			err = e
+			return false
+		}
+		return result < 0
+	})
+	if err != nil {
+		return err
+	}
+
+	sorted := make([]object.Object, len(order))
+	for i, idx := range order {
+		sorted[i] = elements[idx]
+	}
+	return &object.Array{Elements: sorted}
+}
+
+//compareObjects is the ordering sort uses when it isn't given a comparator, integers and strings can be compared among themselves.
+func compareObjects(a, b object.Object) (int, *object.Error) {
+	if isNumber(a) && isNumber(b) && !(a.Type() == object.INTEGER_OBJ && b.Type() == object.INTEGER_OBJ) {
+		switch {
+		case toFloat(a) < toFloat(b):
+			return -1, nil
+		case toFloat(a) > toFloat(b):
+			return 1, nil
+		}
+		return 0, nil
+	}
+	switch a := a.(type) {
+	case *object.Integer:
+		if b, ok := b.(*object.Integer); ok {
+			switch {
+			case a.Value < b.Value:
+				return -1, nil
+			case a.Value > b.Value:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case *object.Time:
+		if b, ok := b.(*object.Time); ok {
+			return a.Value.Compare(b.Value), nil
+		}
+	case *object.Duration:
+		if b, ok := b.(*object.Duration); ok {
+			switch {
+			case a.Value < b.Value:
+				return -1, nil
+			case a.Value > b.Value:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case *object.String:
+		if b, ok := b.(*object.String); ok {
+			switch {
+			case a.Value < b.Value:
+				return -1, nil
+			case a.Value > b.Value:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, newError("cannot compare %s and %s", a.Type(), b.Type())
+}
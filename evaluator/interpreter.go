@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Neeraj-Natu/shifu/ast"
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+/*
+An Interpreter is what a host program embedding the language runs scripts with.
+It owns the global environment of the scripts it runs, and the builtins that
+need something from the host, like the writer printing goes to, are bound in
+that environment so they shadow the defaults in the builtins table.
+Eval called without an interpreter gets the defaults, which print to STDOUT.
+*/
+type Interpreter struct {
+	out    io.Writer
+	env    *object.Environment
+	root   *os.Root
+	random *randomSource
+	clock  func() time.Time
+}
+
+//NewInterpreter makes an interpreter whose scripts print to out.
+func NewInterpreter(out io.Writer) *Interpreter {
+	interpreter := &Interpreter{out: out, env: object.NewEnvironment(), random: newRandomSource(), clock: time.Now}
+	for name, builtin := range printBuiltins(out) {
+		interpreter.env.Set(name, builtin)
+	}
+	for name, builtin := range randomBuiltins(interpreter.random) {
+		interpreter.env.Set(name, builtin)
+	}
+	interpreter.env.Set("now", nowBuiltin(func() time.Time { return interpreter.clock() }))
+	return interpreter
+}
+
+//Environment is the global environment of the interpreter, variables declared at the top of a script end up here.
+func (i *Interpreter) Environment() *object.Environment {
+	return i.env
+}
+
+//Close releases what the interpreter was given by the host, like the directory files are allowed in.
+func (i *Interpreter) Close() error {
+	if i.root == nil {
+		return nil
+	}
+	err := i.root.Close()
+	i.root = nil
+	return err
+}
+
+//Eval evaluates a resolved program in the global environment of the interpreter.
+func (i *Interpreter) Eval(program *ast.Program) object.Object {
+	return Eval(program, i.env)
+}
+
+func init() {
+	for name, builtin := range printBuiltins(os.Stdout) {
+		builtins[name] = builtin
+	}
+}
+
+//printBuiltins makes the builtins that write to out, puts writes every argument on a line of its own,
+//print writes them separated by spaces without a newline and printf writes them formatted like format does.
+func printBuiltins(out io.Writer) map[string]*object.Builtin {
+	return map[string]*object.Builtin{
+		"puts": {Fn: func(args ...object.Object) object.Object {
+			for _, arg := range args {
+				io.WriteString(out, arg.Inspect()+"\n")
+			}
+			return NULL
+		}},
+		"print": {Fn: func(args ...object.Object) object.Object {
+			values := make([]string, len(args))
+			for i, arg := range args {
+				values[i] = arg.Inspect()
+			}
+			io.WriteString(out, strings.Join(values, " "))
+			return NULL
+		}},
+		"printf": {Fn: func(args ...object.Object) object.Object {
+			formatted := formatBuiltin("printf", args)
+			if isError(formatted) {
+				return formatted
+			}
+			io.WriteString(out, formatted.(*object.String).Value)
+			return NULL
+		}},
+	}
+}
@@ -0,0 +1,243 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//The builtins to read and write JSON.
+func init() {
+	builtins["json_parse"] = &object.Builtin{Fn: jsonParse}
+	builtins["json_stringify"] = &object.Builtin{Fn: jsonStringify}
+}
+
+//json_parse turns JSON text into values, objects become hashes that keep the keys in the order they were written.
+//Whole numbers become integers and the rest floats, a whole number too big for an integer is an error
+//instead of a float that silently lost digits.
+func jsonParse(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return argumentError("json_parse", 0, "a STRING", args[0])
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(str.Value))
+	decoder.UseNumber()
+	value := decodeJSON(decoder)
+	if isError(value) {
+		return value
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return newError("invalid JSON: unexpected data after the value")
+	}
+	return value
+}
+
+//decodeJSON reads the next value from the decoder, token by token so that the order of the keys in an object is kept.
+func decodeJSON(decoder *json.Decoder) object.Object {
+	tok, err := decoder.Token()
+	if err != nil {
+		return jsonError(err)
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			elements := []object.Object{}
+			for decoder.More() {
+				el := decodeJSON(decoder)
+				if isError(el) {
+					return el
+				}
+				elements = append(elements, el)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return jsonError(err)
+			}
+			return &object.Array{Elements: elements}
+		}
+
+		hash := object.NewHash()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return jsonError(err)
+			}
+			value := decodeJSON(decoder)
+			if isError(value) {
+				return value
+			}
+			hash.Set(&object.String{Value: key.(string)}, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return jsonError(err)
+		}
+		return hash
+	case json.Number:
+		return jsonNumber(tok)
+	case string:
+		return &object.String{Value: tok}
+	case bool:
+		return nativeBoolToBooleanObject(tok)
+	default:
+		return NULL
+	}
+}
+
+func jsonNumber(number json.Number) object.Object {
+	if !strings.ContainsAny(string(number), ".eE") {
+		value, err := strconv.ParseInt(string(number), 10, 64)
+		if err != nil {
+			return newError("JSON number %s is too big for an INTEGER", number)
+		}
+		return &object.Integer{Value: value}
+	}
+	value, err := strconv.ParseFloat(string(number), 64)
+	if err != nil {
+		return newError("JSON number %s is too big for a FLOAT", number)
+	}
+	return &object.Float{Value: value}
+}
+
+func jsonError(err error) *object.Error {
+	if err == io.EOF {
+		return newError("invalid JSON: unexpected end of JSON input")
+	}
+	return newError("invalid JSON: %s", err)
+}
+
+//json_stringify writes a value as JSON, keys come out in the order of the hash. An indent, either a number of
+//spaces or a string, puts every element on its own line. Sets are written as arrays and times as strings.
+func jsonStringify(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return wrongNumberOfArguments(len(args), 1, 2)
+	}
+
+	var out bytes.Buffer
+	if err := encodeJSON(&out, args[0], make(map[object.Object]bool)); err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return &object.String{Value: out.String()}
+	}
+
+	var indent string
+	switch arg := args[1].(type) {
+	case *object.Integer:
+		if arg.Value < 0 {
+			return newError("negative indent: %d", arg.Value)
+		}
+		if arg.Value > maxStringLength {
+			return newError("indent too big: %d", arg.Value)
+		}
+		indent = strings.Repeat(" ", int(arg.Value))
+	case *object.String:
+		indent = arg.Value
+	default:
+		return argumentError("json_stringify", 1, "an INTEGER or a STRING", arg)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out.Bytes(), "", indent); err != nil {
+		return newError("json_stringify: %s", err)
+	}
+	return &object.String{Value: indented.String()}
+}
+
+//encodeJSON keeps the arrays, hashes and sets it is inside of in visiting, JSON can't write a value that holds itself.
+func encodeJSON(out *bytes.Buffer, obj object.Object, visiting map[object.Object]bool) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Null:
+		out.WriteString("null")
+	case *object.Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.Float:
+		number, err := json.Marshal(obj.Value)
+		if err != nil {
+			return newError("cannot convert %s to JSON", obj.Inspect())
+		}
+		out.Write(number)
+	case *object.String:
+		writeJSONString(out, obj.Value)
+	case *object.Time:
+		writeJSONString(out, obj.Inspect())
+	case *object.Array, *object.Hash, *object.Set:
+		if visiting[obj] {
+			return newError("cannot convert %s that contains itself to JSON", obj.Type())
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		return encodeJSONCollection(out, obj, visiting)
+	default:
+		return newError("cannot convert %s to JSON", obj.Type())
+	}
+	return nil
+}
+
+func encodeJSONCollection(out *bytes.Buffer, obj object.Object, visiting map[object.Object]bool) *object.Error {
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		var elements []object.Object
+		if array, ok := obj.(*object.Array); ok {
+			elements = array.Elements
+		} else {
+			elements = obj.(*object.Set).Elements()
+		}
+		out.WriteByte('[')
+		for i, el := range elements {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, el, visiting); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+		return nil
+	}
+
+	//1 and "1" would both be written as "1", a reader of the JSON could only keep one of them
+	names := make(map[string]bool)
+	out.WriteByte('{')
+	for i, pair := range hash.Pairs() {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		var name string
+		switch key := pair.Key.(type) {
+		case *object.String:
+			name = key.Value
+		case *object.Integer:
+			name = strconv.FormatInt(key.Value, 10)
+		default:
+			return newError("cannot convert %s key to JSON", key.Type())
+		}
+		if names[name] {
+			return newError("cannot convert HASH to JSON, more than one key is written as %s", repr(&object.String{Value: name}))
+		}
+		names[name] = true
+		writeJSONString(out, name)
+		out.WriteByte(':')
+		if err := encodeJSON(out, pair.Value, visiting); err != nil {
+			return err
+		}
+	}
+	out.WriteByte('}')
+	return nil
+}
+
+//writeJSONString quotes a string the way JSON does, without the escaping of '<', '>' and '&' meant for HTML.
+func writeJSONString(out *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	out.Truncate(out.Len() - 1)
+}
@@ -0,0 +1,228 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//constants are the values scripts can use by name like builtins, they can't be assigned to.
+var constants = map[string]object.Object{
+	"PI":      &object.Float{Value: math.Pi},
+	"E":       &object.Float{Value: math.E},
+	"INF":     &object.Float{Value: math.Inf(1)},
+	"NAN":     &object.Float{Value: math.NaN()},
+	"MAX_INT": &object.Integer{Value: math.MaxInt64},
+	"MIN_INT": &object.Integer{Value: math.MinInt64},
+}
+
+//The math builtins take integers and floats alike. The ones whose result is only
+//whole for whole arguments, like abs and pow, keep integers integers.
+func init() {
+	builtins["abs"] = &object.Builtin{Fn: absBuiltin}
+	builtins["min"] = &object.Builtin{Fn: func(args ...object.Object) object.Object { return extremum("min", -1, args) }}
+	builtins["max"] = &object.Builtin{Fn: func(args ...object.Object) object.Object { return extremum("max", 1, args) }}
+	builtins["pow"] = &object.Builtin{Fn: powBuiltin}
+	builtins["floor"] = roundingBuiltin("floor", math.Floor)
+	builtins["ceil"] = roundingBuiltin("ceil", math.Ceil)
+	builtins["round"] = roundingBuiltin("round", math.Round)
+	builtins["gcd"] = &object.Builtin{Fn: gcdBuiltin}
+	builtins["log"] = &object.Builtin{Fn: logBuiltin}
+
+	builtins["sqrt"] = floatBuiltinFunction("sqrt", math.Sqrt, func(x float64) bool { return x >= 0 })
+	builtins["exp"] = floatBuiltinFunction("exp", math.Exp, nil)
+	builtins["sin"] = floatBuiltinFunction("sin", math.Sin, nil)
+	builtins["cos"] = floatBuiltinFunction("cos", math.Cos, nil)
+	builtins["tan"] = floatBuiltinFunction("tan", math.Tan, nil)
+	builtins["asin"] = floatBuiltinFunction("asin", math.Asin, func(x float64) bool { return -1 <= x && x <= 1 })
+	builtins["acos"] = floatBuiltinFunction("acos", math.Acos, func(x float64) bool { return -1 <= x && x <= 1 })
+	builtins["atan"] = floatBuiltinFunction("atan", math.Atan, nil)
+}
+
+//numberArgument returns the argument as a float, or the error telling it isn't a number.
+func numberArgument(name string, position int, arg object.Object) (float64, *object.Error) {
+	if !isNumber(arg) {
+		return 0, argumentError(name, position, "an INTEGER or a FLOAT", arg)
+	}
+	return toFloat(arg), nil
+}
+
+func domainError(name string, args ...object.Object) *object.Error {
+	values := ""
+	for i, arg := range args {
+		if i > 0 {
+			values += ", "
+		}
+		values += arg.Inspect()
+	}
+	return newError("math domain error: %s(%s)", name, values)
+}
+
+//floatBuiltinFunction makes a builtin of a function of one float, inDomain tells which arguments it's defined for, nil meaning all of them.
+func floatBuiltinFunction(name string, fn func(float64) float64, inDomain func(float64) bool) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongNumberOfArguments(len(args), 1, 1)
+		}
+		x, err := numberArgument(name, 0, args[0])
+		if err != nil {
+			return err
+		}
+		if inDomain != nil && !inDomain(x) {
+			return domainError(name, args[0])
+		}
+		return &object.Float{Value: fn(x)}
+	}}
+}
+
+//roundingBuiltin makes floor, ceil and round, they give an integer.
+func roundingBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongNumberOfArguments(len(args), 1, 1)
+		}
+		if integer, ok := args[0].(*object.Integer); ok {
+			return integer
+		}
+		x, err := numberArgument(name, 0, args[0])
+		if err != nil {
+			return err
+		}
+		rounded := fn(x)
+		if math.IsNaN(rounded) || rounded >= math.MaxInt64 || rounded < math.MinInt64 {
+			return domainError(name, args[0])
+		}
+		return &object.Integer{Value: int64(rounded)}
+	}}
+}
+
+func absBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		if arg.Value == math.MinInt64 {
+			return newError("integer overflow: abs(%d)", arg.Value)
+		}
+		if arg.Value < 0 {
+			return &object.Integer{Value: -arg.Value}
+		}
+		return arg
+	case *object.Float:
+		return &object.Float{Value: math.Abs(arg.Value)}
+	default:
+		return argumentError("abs", 0, "an INTEGER or a FLOAT", arg)
+	}
+}
+
+//extremum finds the smallest or the largest of its arguments, or of the elements of a single array argument.
+//Numbers and strings can be compared, the first of equal values is the one returned.
+func extremum(name string, sign int, args []object.Object) object.Object {
+	if len(args) == 1 {
+		if arr, ok := args[0].(*object.Array); ok {
+			if len(arr.Elements) == 0 {
+				return newError("'%s' of an empty ARRAY", name)
+			}
+			args = arr.Elements
+		}
+	}
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, expected at least 1")
+	}
+
+	best := args[0]
+	for _, arg := range args[1:] {
+		cmp, err := compareObjects(arg, best)
+		if err != nil {
+			return err
+		}
+		if cmp*sign > 0 {
+			best = arg
+		}
+	}
+	if _, err := compareObjects(best, best); err != nil {
+		return err
+	}
+	return best
+}
+
+//pow keeps integers integers when the exponent isn't negative, an integer result too big for an INTEGER is an error.
+func powBuiltin(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongNumberOfArguments(len(args), 2, 2)
+	}
+	base, err := numberArgument("pow", 0, args[0])
+	if err != nil {
+		return err
+	}
+	exponent, err := numberArgument("pow", 1, args[1])
+	if err != nil {
+		return err
+	}
+
+	b, bIsInt := args[0].(*object.Integer)
+	e, eIsInt := args[1].(*object.Integer)
+	if bIsInt && eIsInt && e.Value >= 0 {
+		//only 0, 1 and -1 stay small with a big exponent, the rest is bound to overflow so big.Int isn't asked to work it out
+		if e.Value > 64 && (b.Value < -1 || b.Value > 1) {
+			return newError("integer overflow: pow(%d, %d)", b.Value, e.Value)
+		}
+		result := new(big.Int).Exp(big.NewInt(b.Value), big.NewInt(e.Value), nil)
+		if !result.IsInt64() {
+			return newError("integer overflow: pow(%d, %d)", b.Value, e.Value)
+		}
+		return &object.Integer{Value: result.Int64()}
+	}
+
+	result := math.Pow(base, exponent)
+	if math.IsNaN(result) && !math.IsNaN(base) && !math.IsNaN(exponent) {
+		return domainError("pow", args[0], args[1])
+	}
+	return &object.Float{Value: result}
+}
+
+//log is the natural logarithm, or the logarithm in the base given as the second argument.
+func logBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return wrongNumberOfArguments(len(args), 1, 2)
+	}
+	x, err := numberArgument("log", 0, args[0])
+	if err != nil {
+		return err
+	}
+	if x <= 0 {
+		return domainError("log", args...)
+	}
+	if len(args) == 1 {
+		return &object.Float{Value: math.Log(x)}
+	}
+	base, err := numberArgument("log", 1, args[1])
+	if err != nil {
+		return err
+	}
+	if base <= 0 || base == 1 {
+		return domainError("log", args...)
+	}
+	return &object.Float{Value: math.Log(x) / math.Log(base)}
+}
+
+//gcd is the greatest common divisor of its integer arguments, it is never negative.
+func gcdBuiltin(args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return newError("wrong number of arguments. got=0, expected at least 1")
+	}
+	result := new(big.Int)
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return argumentError("gcd", i, "an INTEGER", arg)
+		}
+		result.GCD(nil, nil, result, new(big.Int).Abs(big.NewInt(integer.Value)))
+	}
+	if !result.IsInt64() {
+		return newError("integer overflow: gcd of %d", math.MinInt64)
+	}
+	return &object.Integer{Value: result.Int64()}
+}
@@ -0,0 +1,78 @@
+package evaluator
+
+import "github.com/Neeraj-Natu/shifu/object"
+
+/*
+Values of the builtin types have methods, 'xs.push(1)' is the same as
+'push(xs, 1)'. Every method is a builtin function that gets the value
+it's called on as its first argument, the tables below list which
+builtins are methods of which type.
+*/
+var methods = map[object.ObjectType][]string{
+	object.ARRAY_OBJ: {
+		"len", "first", "last", "push", "pop", "slice", "concat", "indexOf", "join", "freeze",
+		"map", "filter", "reduce", "find", "any", "all", "sort", "choice", "shuffle",
+	},
+	object.FLOAT_OBJ: {
+		"abs", "floor", "ceil", "round",
+	},
+	object.STRING_OBJ: {
+		"len", "split", "trim", "upper", "lower", "replace", "contains", "startsWith", "endsWith",
+		"indexOf", "repeat", "padLeft", "padRight", "slice",
+	},
+	object.HASH_OBJ: {
+		"len", "keys", "values", "has", "delete", "merge", "freeze",
+	},
+	object.SET_OBJ: {
+		"len", "has", "union", "intersection", "difference",
+	},
+	object.REGEX_OBJ: {
+		"match", "find_all",
+	},
+	object.TIME_OBJ: {
+		"format_time", "unix", "utc", "year", "month", "day", "hour", "minute", "second", "weekday",
+	},
+	object.DURATION_OBJ: {
+		"seconds",
+	},
+	object.BYTES_OBJ: {
+		"len", "base64_encode", "base64url_encode", "hex_encode", "md5", "sha1", "sha256", "sha512", "crc32",
+	},
+}
+
+//lookupMethod returns the method with the given name bound to obj, so calling it passes obj as the first argument.
+//The builtin is looked up in env first, an interpreter binds the builtins it gives its own random numbers
+//or files there. A variable shadowing the builtin with anything but a builtin doesn't change the method.
+func lookupMethod(obj object.Object, name string, env *object.Environment) (*object.Builtin, bool) {
+	for _, method := range methods[obj.Type()] {
+		if method == name {
+			builtin := builtins[name]
+			if bound, ok := env.Get(name); ok {
+				if bound, ok := bound.(*object.Builtin); ok {
+					builtin = bound
+				}
+			}
+			return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+				return builtin.Fn(append([]object.Object{obj}, args...)...)
+			}}, true
+		}
+	}
+	return nil, false
+}
+
+//On a hash 'h.name' is the same as 'h["name"]' and a key wins over a method of the same name,
+//a hash without such a key or method gives null just like indexing it would.
+func evalMemberExpression(obj object.Object, name string, env *object.Environment) object.Object {
+	if hash, ok := obj.(*object.Hash); ok {
+		if pair, ok := hash.Get(&object.String{Value: name}); ok {
+			return pair.Value
+		}
+	}
+	if method, ok := lookupMethod(obj, name, env); ok {
+		return method
+	}
+	if obj.Type() == object.HASH_OBJ {
+		return NULL
+	}
+	return newError("unknown member: %s.%s", obj.Type(), name)
+}
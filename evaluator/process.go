@@ -0,0 +1,121 @@
+package evaluator
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//Like the file builtins these are an error until the host allows them, a script embedded in
+//another program has no business reading its environment or ending it.
+func init() {
+	for name, builtin := range processBuiltins(Process{}) {
+		builtins[name] = builtin
+	}
+}
+
+//Process is what a host shares of the process running the scripts, a field left empty keeps the builtins using it disabled.
+//Exit is called by 'exit' with the status, when it returns instead of ending the process the script is stopped with an error.
+type Process struct {
+	Args  []string
+	Env   func(name string) (string, bool)
+	Stdin io.Reader
+	Exit  func(code int)
+}
+
+//AllowProcess binds the builtins of the process in the environment of the interpreter.
+func (i *Interpreter) AllowProcess(process Process) {
+	for name, builtin := range processBuiltins(process) {
+		i.env.Set(name, builtin)
+	}
+}
+
+/*
+processBuiltins makes the builtins for the parts of the process that were shared.
+	args()       the command line arguments given after the script
+	env(name)    the value of the environment variable, null when it isn't set
+	exit(code)   ends the script with the status code
+	read_line()  the next line of the standard input without the line ending, null once it's all read
+	read_all()   the rest of the standard input
+*/
+func processBuiltins(process Process) map[string]*object.Builtin {
+	var stdin *bufio.Reader
+	if process.Stdin != nil {
+		stdin = bufio.NewReader(process.Stdin)
+	}
+
+	processBuiltins := map[string]*object.Builtin{
+		"args": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return wrongNumberOfArguments(len(args), 0, 0)
+			}
+			return stringArray(process.Args)
+		}},
+		"env": {Fn: func(args ...object.Object) object.Object {
+			values, err := stringArguments("env", args, 1, 1)
+			if err != nil {
+				return err
+			}
+			if value, ok := process.Env(values[0]); ok {
+				return &object.String{Value: value}
+			}
+			return NULL
+		}},
+		"exit": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return wrongNumberOfArguments(len(args), 1, 1)
+			}
+			code, ok := args[0].(*object.Integer)
+			if !ok {
+				return argumentError("exit", 0, "an INTEGER", args[0])
+			}
+			process.Exit(int(code.Value))
+			return newError("exited with status %d", code.Value)
+		}},
+		"read_line": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return wrongNumberOfArguments(len(args), 0, 0)
+			}
+			line, err := stdin.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return newError("read_line: %s", err)
+			}
+			if err == io.EOF && line == "" {
+				return NULL
+			}
+			return &object.String{Value: strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")}
+		}},
+		"read_all": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return wrongNumberOfArguments(len(args), 0, 0)
+			}
+			contents, err := io.ReadAll(stdin)
+			if err != nil {
+				return newError("read_all: %s", err)
+			}
+			return &object.String{Value: string(contents)}
+		}},
+	}
+
+	allowed := map[string]bool{
+		"args":      process.Args != nil,
+		"env":       process.Env != nil,
+		"exit":      process.Exit != nil,
+		"read_line": stdin != nil,
+		"read_all":  stdin != nil,
+	}
+	for name := range processBuiltins {
+		if !allowed[name] {
+			processBuiltins[name] = &object.Builtin{Fn: processNotAllowed(name)}
+		}
+	}
+	return processBuiltins
+}
+
+func processNotAllowed(name string) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		return newError("'%s' needs access to the process, which the host hasn't allowed", name)
+	}
+}
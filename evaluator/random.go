@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//Every interpreter has a generator of its own so scripts don't see each other's numbers and a seed
+//makes a run repeatable. Eval without an interpreter shares the one made here, it is locked so
+//scripts evaluated at the same time can use it.
+func init() {
+	for name, builtin := range randomBuiltins(newRandomSource()) {
+		builtins[name] = builtin
+	}
+}
+
+//randomSeedStream is the second half of the PCG state, seeds only choose the first.
+const randomSeedStream = 0x5368696675
+
+//randomSource is a PCG generator that can be used from more than one goroutine.
+type randomSource struct {
+	mu  sync.Mutex
+	pcg *rand.PCG
+}
+
+func newRandomSource() *randomSource {
+	return &randomSource{pcg: rand.NewPCG(rand.Uint64(), randomSeedStream)}
+}
+
+func (s *randomSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pcg.Uint64()
+}
+
+func (s *randomSource) Seed(seed uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pcg.Seed(seed, randomSeedStream)
+}
+
+//SeedRandom makes the random numbers the scripts of the interpreter get the same on every run, like calling 'seed' does.
+func (i *Interpreter) SeedRandom(seed int64) {
+	i.random.Seed(uint64(seed))
+}
+
+/*
+randomBuiltins makes the builtins drawing from source.
+	random()            a float from 0 up to but not including 1
+	random_int(lo, hi)  an integer from lo to hi, both included
+	choice(arr)         an element of the array
+	shuffle(arr)        a new array with the elements of arr in a random order
+	seed(n)             starts the numbers over from the seed n
+*/
+func randomBuiltins(source *randomSource) map[string]*object.Builtin {
+	r := rand.New(source)
+
+	return map[string]*object.Builtin{
+		"random": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return wrongNumberOfArguments(len(args), 0, 0)
+			}
+			return &object.Float{Value: r.Float64()}
+		}},
+		"random_int": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return wrongNumberOfArguments(len(args), 2, 2)
+			}
+			lo, ok := args[0].(*object.Integer)
+			if !ok {
+				return argumentError("random_int", 0, "an INTEGER", args[0])
+			}
+			hi, ok := args[1].(*object.Integer)
+			if !ok {
+				return argumentError("random_int", 1, "an INTEGER", args[1])
+			}
+			if lo.Value > hi.Value {
+				return newError("empty range for 'random_int': %d to %d", lo.Value, hi.Value)
+			}
+			//the size of the range is worked out in uint64, it's 0 only when the range is all of int64
+			size := uint64(hi.Value-lo.Value) + 1
+			if size == 0 {
+				return &object.Integer{Value: int64(r.Uint64())}
+			}
+			return &object.Integer{Value: lo.Value + int64(r.Uint64N(size))}
+		}},
+		"choice": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return wrongNumberOfArguments(len(args), 1, 1)
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return argumentError("choice", 0, "an ARRAY", args[0])
+			}
+			if len(arr.Elements) == 0 {
+				return newError("'choice' of an empty ARRAY")
+			}
+			return arr.Elements[r.IntN(len(arr.Elements))]
+		}},
+		"shuffle": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return wrongNumberOfArguments(len(args), 1, 1)
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return argumentError("shuffle", 0, "an ARRAY", args[0])
+			}
+			elements := make([]object.Object, len(arr.Elements))
+			copy(elements, arr.Elements)
+			r.Shuffle(len(elements), func(i, j int) {
+				elements[i], elements[j] = elements[j], elements[i]
+			})
+			return &object.Array{Elements: elements}
+		}},
+		"seed": {Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return wrongNumberOfArguments(len(args), 1, 1)
+			}
+			n, ok := args[0].(*object.Integer)
+			if !ok {
+				return argumentError("seed", 0, "an INTEGER", args[0])
+			}
+			source.Seed(uint64(n.Value))
+			return NULL
+		}},
+	}
+}
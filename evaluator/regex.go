@@ -0,0 +1,138 @@
+package evaluator
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//The builtins for regular expressions, 'replace' and 'split' take a regex in place of the string to look for as well.
+func init() {
+	builtins["regex"] = &object.Builtin{Fn: regexBuiltin}
+	builtins["match"] = &object.Builtin{Fn: matchBuiltin}
+	builtins["find_all"] = &object.Builtin{Fn: findAllBuiltin}
+}
+
+func regexBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	pattern, ok := args[0].(*object.String)
+	if !ok {
+		return argumentError("regex", 0, "a STRING", args[0])
+	}
+	re, err := regexp.Compile(pattern.Value)
+	if err != nil {
+		return newError("invalid regex: %s", err)
+	}
+	return &object.Regex{Value: re}
+}
+
+//regexAndString checks the arguments of a builtin taking a regex and the string to look in,
+//a string is taken as a pattern and compiled.
+func regexAndString(name string, args []object.Object) (*regexp.Regexp, string, *object.Error) {
+	if len(args) != 2 {
+		return nil, "", wrongNumberOfArguments(len(args), 2, 2)
+	}
+	str, ok := args[1].(*object.String)
+	if !ok {
+		return nil, "", argumentError(name, 1, "a STRING", args[1])
+	}
+	switch arg := args[0].(type) {
+	case *object.Regex:
+		return arg.Value, str.Value, nil
+	case *object.String:
+		re := regexBuiltin(arg)
+		if isError(re) {
+			return nil, "", re.(*object.Error)
+		}
+		return re.(*object.Regex).Value, str.Value, nil
+	default:
+		return nil, "", argumentError(name, 0, "a REGEX or a STRING", arg)
+	}
+}
+
+//match looks for the first match of the regex in the string, it gives null when there is none.
+func matchBuiltin(args ...object.Object) object.Object {
+	re, str, err := regexAndString("match", args)
+	if err != nil {
+		return err
+	}
+	indexes := re.FindStringSubmatchIndex(str)
+	if indexes == nil {
+		return NULL
+	}
+	return matchHash(re, str, indexes)
+}
+
+//find_all gives every match of the regex in the string, they don't overlap.
+func findAllBuiltin(args ...object.Object) object.Object {
+	re, str, err := regexAndString("find_all", args)
+	if err != nil {
+		return err
+	}
+	elements := []object.Object{}
+	for _, indexes := range re.FindAllStringSubmatchIndex(str, -1) {
+		elements = append(elements, matchHash(re, str, indexes))
+	}
+	return &object.Array{Elements: elements}
+}
+
+//matchHash describes a match: the text that matched, where it starts and ends counted in characters like string indexes are,
+//the text of every group and a hash of the named groups. A group that took no part in the match is null.
+func matchHash(re *regexp.Regexp, str string, indexes []int) *object.Hash {
+	groups := []object.Object{}
+	named := object.NewHash()
+	for i, name := range re.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		var group object.Object = NULL
+		if indexes[2*i] >= 0 {
+			group = &object.String{Value: str[indexes[2*i]:indexes[2*i+1]]}
+		}
+		groups = append(groups, group)
+		if name != "" {
+			named.Set(&object.String{Value: name}, group)
+		}
+	}
+
+	hash := object.NewHash()
+	hash.Set(&object.String{Value: "match"}, &object.String{Value: str[indexes[0]:indexes[1]]})
+	hash.Set(&object.String{Value: "start"}, &object.Integer{Value: int64(utf8.RuneCountInString(str[:indexes[0]]))})
+	hash.Set(&object.String{Value: "end"}, &object.Integer{Value: int64(utf8.RuneCountInString(str[:indexes[1]]))})
+	hash.Set(&object.String{Value: "groups"}, &object.Array{Elements: groups})
+	hash.Set(&object.String{Value: "named"}, named)
+	return hash
+}
+
+//regexReplace replaces every match of the regex. The replacement is either a string where '$1' or '${name}'
+//stand for the groups, or a function that gets the match hash and returns the string to put in its place.
+func regexReplace(str string, re *regexp.Regexp, replacement object.Object) object.Object {
+	switch replacement := replacement.(type) {
+	case *object.String:
+		return &object.String{Value: re.ReplaceAllString(str, replacement.Value)}
+	case *object.Function, *object.Builtin:
+		var out strings.Builder
+		last := 0
+		for _, indexes := range re.FindAllStringSubmatchIndex(str, -1) {
+			result := applyFunction(replacement, []object.Object{matchHash(re, str, indexes)})
+			if isError(result) {
+				return result
+			}
+			value, ok := result.(*object.String)
+			if !ok {
+				return newError("function passed to 'replace' must return a STRING, got %s", result.Type())
+			}
+			out.WriteString(str[last:indexes[0]])
+			out.WriteString(value.Value)
+			last = indexes[1]
+		}
+		out.WriteString(str[last:])
+		return &object.String{Value: out.String()}
+	default:
+		return argumentError("replace", 2, "a STRING or a FUNCTION", replacement)
+	}
+}
@@ -0,0 +1,140 @@
+package evaluator
+
+import (
+	"github.com/Neeraj-Natu/shifu/ast"
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+/*
+Sets are written '#{1, 2, 3}' and can hold anything a hash can have
+as a key. The set algebra is available both as the operators
+'a | b' (union), 'a & b' (intersection) and 'a - b' (difference) and
+as the builtins of the same name. All of them make a new set, the
+elements keep the order of the left set followed by the new ones of
+the right set.
+*/
+func init() {
+	builtins["set"] = &object.Builtin{Fn: setBuiltin}
+	builtins["union"] = &object.Builtin{Fn: setAlgebraBuiltin("union", setUnion)}
+	builtins["intersection"] = &object.Builtin{Fn: setAlgebraBuiltin("intersection", setIntersection)}
+	builtins["difference"] = &object.Builtin{Fn: setAlgebraBuiltin("difference", setDifference)}
+}
+
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+	set := object.NewSet()
+	for _, elementNode := range node.Elements {
+		el := Eval(elementNode, env)
+		if isError(el) {
+			return el
+		}
+		if err := addToSet(set, el); err != nil {
+			return err
+		}
+	}
+	return set
+}
+
+func addToSet(set *object.Set, el object.Object) *object.Error {
+	key, ok := object.AsHashable(el)
+	if !ok {
+		return newError("unusable as set element: %s", el.Type())
+	}
+	set.Add(key)
+	return nil
+}
+
+func evalSetInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Set)
+	rightVal := right.(*object.Set)
+
+	switch operator {
+	case "|":
+		return setUnion(leftVal, rightVal)
+	case "&":
+		return setIntersection(leftVal, rightVal)
+	case "-":
+		return setDifference(leftVal, rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(object.Equals(left, right))
+	case "!=":
+		return nativeBoolToBooleanObject(!object.Equals(left, right))
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func setUnion(a, b *object.Set) *object.Set {
+	result := object.NewSet()
+	for _, set := range []*object.Set{a, b} {
+		for _, el := range set.Elements() {
+			result.Add(el.(object.Hashable))
+		}
+	}
+	return result
+}
+
+func setIntersection(a, b *object.Set) *object.Set {
+	result := object.NewSet()
+	for _, el := range a.Elements() {
+		if b.Has(el.(object.Hashable)) {
+			result.Add(el.(object.Hashable))
+		}
+	}
+	return result
+}
+
+func setDifference(a, b *object.Set) *object.Set {
+	result := object.NewSet()
+	for _, el := range a.Elements() {
+		if !b.Has(el.(object.Hashable)) {
+			result.Add(el.(object.Hashable))
+		}
+	}
+	return result
+}
+
+//set makes a set of the elements of an array, the characters of a string or the keys of a hash, or an empty set without an argument.
+func setBuiltin(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return wrongNumberOfArguments(len(args), 0, 1)
+	}
+	set := object.NewSet()
+	if len(args) == 0 {
+		return set
+	}
+	if _, ok := args[0].(*object.Set); ok {
+		return args[0]
+	}
+	elements, err := iterate(args[0])
+	if err != nil {
+		return argumentError("set", 0, "an ARRAY, a STRING or a HASH", args[0])
+	}
+	for _, el := range elements {
+		if err := addToSet(set, el); err != nil {
+			return err
+		}
+	}
+	return set
+}
+
+//setAlgebraBuiltin makes the builtin for one of the set operations, it takes two or more sets and combines them from left to right.
+func setAlgebraBuiltin(name string, operation func(a, b *object.Set) *object.Set) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		if len(args) < 2 {
+			return newError("wrong number of arguments. got=%d, expected at least 2", len(args))
+		}
+		sets := make([]*object.Set, len(args))
+		for i, arg := range args {
+			set, ok := arg.(*object.Set)
+			if !ok {
+				return argumentError(name, i, "a SET", arg)
+			}
+			sets[i] = set
+		}
+		result := sets[0]
+		for _, set := range sets[1:] {
+			result = operation(result, set)
+		}
+		return result
+	}
+}
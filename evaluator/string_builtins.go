@@ -0,0 +1,267 @@
+package evaluator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+/*
+The builtins working on strings. Positions, lengths and widths in all of
+them count characters (unicode code points) and not bytes, so "héllo"
+has a length of 5 and its "l" is at index 2.
+*/
+func init() {
+	builtins["split"] = &object.Builtin{Fn: splitBuiltin}
+	builtins["join"] = &object.Builtin{Fn: joinBuiltin}
+	builtins["trim"] = &object.Builtin{Fn: trimBuiltin}
+	builtins["upper"] = &object.Builtin{Fn: upperBuiltin}
+	builtins["lower"] = &object.Builtin{Fn: lowerBuiltin}
+	builtins["replace"] = &object.Builtin{Fn: replaceBuiltin}
+	builtins["contains"] = &object.Builtin{Fn: containsBuiltin}
+	builtins["startsWith"] = &object.Builtin{Fn: startsWithBuiltin}
+	builtins["endsWith"] = &object.Builtin{Fn: endsWithBuiltin}
+	builtins["indexOf"] = &object.Builtin{Fn: indexOfBuiltin}
+	builtins["repeat"] = &object.Builtin{Fn: repeatBuiltin}
+	builtins["padLeft"] = &object.Builtin{Fn: padLeftBuiltin}
+	builtins["padRight"] = &object.Builtin{Fn: padRightBuiltin}
+}
+
+//stringArguments checks that the builtin got between min and max arguments and that all of them are strings.
+func stringArguments(name string, args []object.Object, min, max int) ([]string, *object.Error) {
+	if len(args) < min || len(args) > max {
+		return nil, wrongNumberOfArguments(len(args), min, max)
+	}
+	values := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, argumentError(name, i, "a STRING", arg)
+		}
+		values[i] = str.Value
+	}
+	return values, nil
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, value := range values {
+		elements[i] = &object.String{Value: value}
+	}
+	return &object.Array{Elements: elements}
+}
+
+//split cuts the string at every separator, an empty separator splits it into its characters.
+//Without a separator the string is split around runs of whitespace, a regex separator splits at every match.
+func splitBuiltin(args ...object.Object) object.Object {
+	if len(args) == 2 {
+		if re, ok := args[1].(*object.Regex); ok {
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return argumentError("split", 0, "a STRING", args[0])
+			}
+			return stringArray(re.Value.Split(str.Value, -1))
+		}
+	}
+	values, err := stringArguments("split", args, 1, 2)
+	if err != nil {
+		return err
+	}
+	if len(values) == 1 {
+		return stringArray(strings.Fields(values[0]))
+	}
+	return stringArray(strings.Split(values[0], values[1]))
+}
+
+func joinBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return wrongNumberOfArguments(len(args), 1, 2)
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return argumentError("join", 0, "an ARRAY", args[0])
+	}
+	separator := ""
+	if len(args) == 2 {
+		sep, ok := args[1].(*object.String)
+		if !ok {
+			return argumentError("join", 1, "a STRING", args[1])
+		}
+		separator = sep.Value
+	}
+	values := make([]string, len(arr.Elements))
+	for i, el := range arr.Elements {
+		str, ok := el.(*object.String)
+		if !ok {
+			return newError("elements of the array passed to 'join' must be STRING, got %s", el.Type())
+		}
+		values[i] = str.Value
+	}
+	return &object.String{Value: strings.Join(values, separator)}
+}
+
+//trim removes whitespace from both ends of the string, or the characters of the second argument when there is one.
+func trimBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("trim", args, 1, 2)
+	if err != nil {
+		return err
+	}
+	if len(values) == 1 {
+		return &object.String{Value: strings.TrimSpace(values[0])}
+	}
+	return &object.String{Value: strings.Trim(values[0], values[1])}
+}
+
+func upperBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("upper", args, 1, 1)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: strings.ToUpper(values[0])}
+}
+
+func lowerBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("lower", args, 1, 1)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: strings.ToLower(values[0])}
+}
+
+//replace replaces every occurrence of the second argument by the third, the second argument can be a regex.
+func replaceBuiltin(args ...object.Object) object.Object {
+	if len(args) == 3 {
+		if re, ok := args[1].(*object.Regex); ok {
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return argumentError("replace", 0, "a STRING", args[0])
+			}
+			return regexReplace(str.Value, re.Value, args[2])
+		}
+	}
+	values, err := stringArguments("replace", args, 3, 3)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: strings.Replace(values[0], values[1], values[2], -1)}
+}
+
+func containsBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("contains", args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return nativeBoolToBooleanObject(strings.Contains(values[0], values[1]))
+}
+
+func startsWithBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("startsWith", args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return nativeBoolToBooleanObject(strings.HasPrefix(values[0], values[1]))
+}
+
+func endsWithBuiltin(args ...object.Object) object.Object {
+	values, err := stringArguments("endsWith", args, 2, 2)
+	if err != nil {
+		return err
+	}
+	return nativeBoolToBooleanObject(strings.HasSuffix(values[0], values[1]))
+}
+
+//indexOf returns where the second argument first shows up in a string, or the index of the first
+//element of an array equal to it. It's -1 when there is no such place.
+func indexOfBuiltin(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongNumberOfArguments(len(args), 2, 2)
+	}
+	switch arg := args[0].(type) {
+	case *object.Array:
+		for i, el := range arg.Elements {
+			if object.Equals(el, args[1]) {
+				return &object.Integer{Value: int64(i)}
+			}
+		}
+		return &object.Integer{Value: -1}
+	case *object.String:
+		sub, ok := args[1].(*object.String)
+		if !ok {
+			return argumentError("indexOf", 1, "a STRING", args[1])
+		}
+		i := strings.Index(arg.Value, sub.Value)
+		if i < 0 {
+			return &object.Integer{Value: -1}
+		}
+		return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value[:i]))}
+	default:
+		return argumentError("indexOf", 0, "an ARRAY or a STRING", args[0])
+	}
+}
+
+func repeatBuiltin(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return wrongNumberOfArguments(len(args), 2, 2)
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return argumentError("repeat", 0, "a STRING", args[0])
+	}
+	count, ok := args[1].(*object.Integer)
+	if !ok {
+		return argumentError("repeat", 1, "an INTEGER", args[1])
+	}
+	return evalStringRepetition(str, count)
+}
+
+func padLeftBuiltin(args ...object.Object) object.Object {
+	return pad("padLeft", args, true)
+}
+
+func padRightBuiltin(args ...object.Object) object.Object {
+	return pad("padRight", args, false)
+}
+
+//pad makes the string as wide as the second argument by repeating the third one, a space by default,
+//in front of it or after it. A string that is wide enough already is returned as it is.
+func pad(name string, args []object.Object, left bool) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return wrongNumberOfArguments(len(args), 2, 3)
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return argumentError(name, 0, "a STRING", args[0])
+	}
+	width, ok := args[1].(*object.Integer)
+	if !ok {
+		return argumentError(name, 1, "an INTEGER", args[1])
+	}
+	if width.Value > maxStringLength {
+		return newError("width passed to '%s' is too big: %d", name, width.Value)
+	}
+	padding := []rune(" ")
+	if len(args) == 3 {
+		padArg, ok := args[2].(*object.String)
+		if !ok {
+			return argumentError(name, 2, "a STRING", args[2])
+		}
+		if padArg.Value == "" {
+			return newError("padding passed to '%s' must not be empty", name)
+		}
+		padding = []rune(padArg.Value)
+	}
+
+	missing := int(width.Value) - utf8.RuneCountInString(str.Value)
+	if missing <= 0 {
+		return str
+	}
+	fill := make([]rune, missing)
+	for i := range fill {
+		fill[i] = padding[i%len(padding)]
+	}
+	if left {
+		return &object.String{Value: string(fill) + str.Value}
+	}
+	return &object.String{Value: str.Value + string(fill)}
+}
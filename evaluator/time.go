@@ -0,0 +1,301 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//'now' asks the clock of the interpreter, Eval without an interpreter gets the time of the system.
+func init() {
+	builtins["now"] = nowBuiltin(time.Now)
+	builtins["date"] = &object.Builtin{Fn: dateBuiltin}
+	builtins["parse_time"] = &object.Builtin{Fn: parseTimeBuiltin}
+	builtins["format_time"] = &object.Builtin{Fn: formatTimeBuiltin}
+	builtins["duration"] = &object.Builtin{Fn: durationBuiltin}
+	builtins["seconds"] = &object.Builtin{Fn: secondsBuiltin}
+	builtins["unix"] = &object.Builtin{Fn: unixBuiltin}
+	builtins["from_unix"] = &object.Builtin{Fn: fromUnixBuiltin}
+	builtins["utc"] = &object.Builtin{Fn: utcBuiltin}
+
+	builtins["year"] = timePart("year", func(t time.Time) object.Object { return &object.Integer{Value: int64(t.Year())} })
+	builtins["month"] = timePart("month", func(t time.Time) object.Object { return &object.Integer{Value: int64(t.Month())} })
+	builtins["day"] = timePart("day", func(t time.Time) object.Object { return &object.Integer{Value: int64(t.Day())} })
+	builtins["hour"] = timePart("hour", func(t time.Time) object.Object { return &object.Integer{Value: int64(t.Hour())} })
+	builtins["minute"] = timePart("minute", func(t time.Time) object.Object { return &object.Integer{Value: int64(t.Minute())} })
+	builtins["second"] = timePart("second", func(t time.Time) object.Object { return &object.Integer{Value: int64(t.Second())} })
+	builtins["weekday"] = timePart("weekday", func(t time.Time) object.Object { return &object.String{Value: t.Weekday().String()} })
+}
+
+//SetClock makes 'now' ask the given function for the time, so a host can run its scripts at a time of its choosing.
+func (i *Interpreter) SetClock(now func() time.Time) {
+	i.clock = now
+}
+
+func nowBuiltin(clock func() time.Time) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return wrongNumberOfArguments(len(args), 0, 0)
+		}
+		return &object.Time{Value: clock()}
+	}}
+}
+
+//date makes the time in UTC from the year, month and day, and optionally the hour, minute and second.
+func dateBuiltin(args ...object.Object) object.Object {
+	if len(args) < 3 || len(args) > 6 {
+		return wrongNumberOfArguments(len(args), 3, 6)
+	}
+	parts := [6]int{}
+	for i, arg := range args {
+		part, ok := arg.(*object.Integer)
+		if !ok {
+			return argumentError("date", i, "an INTEGER", arg)
+		}
+		parts[i] = int(part.Value)
+	}
+	return &object.Time{Value: time.Date(parts[0], time.Month(parts[1]), parts[2], parts[3], parts[4], parts[5], 0, time.UTC)}
+}
+
+//timeLayout is the layout given as the second argument of parse_time or format_time, written the way Go's time package
+//writes them: '2006-01-02 15:04:05'. Without one times are written like '2024-05-01T10:30:00Z'.
+func timeLayout(name string, args []object.Object) (string, *object.Error) {
+	if len(args) < 2 {
+		return time.RFC3339Nano, nil
+	}
+	layout, ok := args[1].(*object.String)
+	if !ok {
+		return "", argumentError(name, 1, "a STRING", args[1])
+	}
+	return layout.Value, nil
+}
+
+func parseTimeBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return wrongNumberOfArguments(len(args), 1, 2)
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return argumentError("parse_time", 0, "a STRING", args[0])
+	}
+	layout, err := timeLayout("parse_time", args)
+	if err != nil {
+		return err
+	}
+	t, parseErr := time.Parse(layout, str.Value)
+	if parseErr != nil {
+		return newError("cannot parse time: %s", parseErr)
+	}
+	return &object.Time{Value: t}
+}
+
+func formatTimeBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return wrongNumberOfArguments(len(args), 1, 2)
+	}
+	t, ok := args[0].(*object.Time)
+	if !ok {
+		return argumentError("format_time", 0, "a TIME", args[0])
+	}
+	layout, err := timeLayout("format_time", args)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: t.Value.Format(layout)}
+}
+
+//duration reads a duration like '1h30m' or '250ms', or makes one of a number of seconds.
+func durationBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.String:
+		d, err := time.ParseDuration(arg.Value)
+		if err != nil {
+			return newError("cannot parse duration: %s", err)
+		}
+		return &object.Duration{Value: d}
+	case *object.Integer, *object.Float:
+		return scaleDuration(time.Second, arg)
+	default:
+		return argumentError("duration", 0, "a STRING, an INTEGER or a FLOAT", arg)
+	}
+}
+
+//seconds is the length of a duration in seconds.
+func secondsBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	d, ok := args[0].(*object.Duration)
+	if !ok {
+		return argumentError("seconds", 0, "a DURATION", args[0])
+	}
+	return &object.Float{Value: d.Value.Seconds()}
+}
+
+//unix is the number of seconds from January 1, 1970 UTC to the time.
+func unixBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	t, ok := args[0].(*object.Time)
+	if !ok {
+		return argumentError("unix", 0, "a TIME", args[0])
+	}
+	return &object.Integer{Value: t.Value.Unix()}
+}
+
+//from_unix is the time in UTC the number of seconds after January 1, 1970 UTC.
+func fromUnixBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return &object.Time{Value: time.Unix(arg.Value, 0).UTC()}
+	case *object.Float:
+		seconds, fraction := math.Modf(arg.Value)
+		return &object.Time{Value: time.Unix(int64(seconds), int64(fraction*1e9)).UTC()}
+	default:
+		return argumentError("from_unix", 0, "an INTEGER or a FLOAT", arg)
+	}
+}
+
+func utcBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	t, ok := args[0].(*object.Time)
+	if !ok {
+		return argumentError("utc", 0, "a TIME", args[0])
+	}
+	return &object.Time{Value: t.Value.UTC()}
+}
+
+//timePart makes the builtin giving one part of a time, in the time zone of the time.
+func timePart(name string, part func(time.Time) object.Object) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongNumberOfArguments(len(args), 1, 1)
+		}
+		t, ok := args[0].(*object.Time)
+		if !ok {
+			return argumentError(name, 0, "a TIME", args[0])
+		}
+		return part(t.Value)
+	}}
+}
+
+//isTimeArithmetic tells if evalTimeInfixExpression knows what to do with the operator and operands.
+func isTimeArithmetic(operator string, left, right object.Object) bool {
+	leftType, rightType := left.Type(), right.Type()
+	switch {
+	case leftType == rightType && (leftType == object.TIME_OBJ || leftType == object.DURATION_OBJ):
+		if operator == "<" || operator == ">" || operator == "==" || operator == "!=" || operator == "-" {
+			return true
+		}
+		return leftType == object.DURATION_OBJ && (operator == "+" || operator == "/")
+	case leftType == object.TIME_OBJ && rightType == object.DURATION_OBJ:
+		return operator == "+" || operator == "-"
+	case leftType == object.DURATION_OBJ && rightType == object.TIME_OBJ:
+		return operator == "+"
+	case leftType == object.DURATION_OBJ && isNumber(right):
+		return operator == "*" || operator == "/"
+	case isNumber(left) && rightType == object.DURATION_OBJ:
+		return operator == "*"
+	}
+	return false
+}
+
+/*
+evalTimeInfixExpression works out the arithmetic of times and durations:
+	time - time              the duration between them
+	time + duration          a later time, '-' gives an earlier one
+	duration + duration      a longer duration, '-' gives a shorter one
+	duration * number        a scaled duration, '/' too
+	duration / duration      how many times the second fits in the first, as a float
+Times and durations compare with '<', '>', '==' and '!='.
+*/
+func evalTimeInfixExpression(operator string, left, right object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Time:
+		if right, ok := right.(*object.Time); ok {
+			switch operator {
+			case "-":
+				return &object.Duration{Value: left.Value.Sub(right.Value)}
+			case "<":
+				return nativeBoolToBooleanObject(left.Value.Before(right.Value))
+			case ">":
+				return nativeBoolToBooleanObject(left.Value.After(right.Value))
+			case "==":
+				return nativeBoolToBooleanObject(left.Value.Equal(right.Value))
+			default:
+				return nativeBoolToBooleanObject(!left.Value.Equal(right.Value))
+			}
+		}
+		d := right.(*object.Duration).Value
+		if operator == "-" {
+			d = -d
+		}
+		return &object.Time{Value: left.Value.Add(d)}
+	case *object.Duration:
+		switch right := right.(type) {
+		case *object.Time:
+			return &object.Time{Value: right.Value.Add(left.Value)}
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Duration{Value: left.Value + right.Value}
+			case "-":
+				return &object.Duration{Value: left.Value - right.Value}
+			case "/":
+				if right.Value == 0 {
+					return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+				}
+				return &object.Float{Value: float64(left.Value) / float64(right.Value)}
+			case "<":
+				return nativeBoolToBooleanObject(left.Value < right.Value)
+			case ">":
+				return nativeBoolToBooleanObject(left.Value > right.Value)
+			case "==":
+				return nativeBoolToBooleanObject(left.Value == right.Value)
+			default:
+				return nativeBoolToBooleanObject(left.Value != right.Value)
+			}
+		default:
+			if operator == "*" {
+				return scaleDuration(left.Value, right)
+			}
+			if toFloat(right) == 0 {
+				return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+			}
+			if n, ok := right.(*object.Integer); ok {
+				return &object.Duration{Value: left.Value / time.Duration(n.Value)}
+			}
+			return scaleDuration(left.Value, &object.Float{Value: 1 / toFloat(right)})
+		}
+	default:
+		return scaleDuration(right.(*object.Duration).Value, left)
+	}
+}
+
+//scaleDuration multiplies the duration by a number, a result too long for a DURATION, about 292 years, is an error.
+//Integers are multiplied exactly, floats are rounded to the nearest nanosecond.
+func scaleDuration(d time.Duration, factor object.Object) object.Object {
+	if n, ok := factor.(*object.Integer); ok {
+		scaled := new(big.Int).Mul(big.NewInt(int64(d)), big.NewInt(n.Value))
+		if !scaled.IsInt64() {
+			return newError("duration out of range: %s * %d", d, n.Value)
+		}
+		return &object.Duration{Value: time.Duration(scaled.Int64())}
+	}
+	scaled := math.Round(float64(d) * toFloat(factor))
+	if math.IsNaN(scaled) || scaled >= math.MaxInt64 || scaled < math.MinInt64 {
+		return newError("duration out of range: %s * %s", d, factor.Inspect())
+	}
+	return &object.Duration{Value: time.Duration(scaled)}
+}
@@ -0,0 +1,196 @@
+package evaluator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Neeraj-Natu/shifu/object"
+)
+
+//The builtins to ask what a value is and to convert values from one type to another.
+func init() {
+	builtins["type"] = &object.Builtin{Fn: typeBuiltin}
+	builtins["int"] = &object.Builtin{Fn: intBuiltin}
+	builtins["float"] = &object.Builtin{Fn: floatBuiltin}
+	builtins["str"] = &object.Builtin{Fn: strBuiltin}
+	builtins["bool"] = &object.Builtin{Fn: boolBuiltin}
+	builtins["repr"] = &object.Builtin{Fn: reprBuiltin}
+
+	builtins["is_int"] = typePredicate(object.INTEGER_OBJ)
+	builtins["is_float"] = typePredicate(object.FLOAT_OBJ)
+	builtins["is_string"] = typePredicate(object.STRING_OBJ)
+	builtins["is_bool"] = typePredicate(object.BOOLEAN_OBJ)
+	builtins["is_array"] = typePredicate(object.ARRAY_OBJ)
+	builtins["is_hash"] = typePredicate(object.HASH_OBJ)
+	builtins["is_set"] = typePredicate(object.SET_OBJ)
+	builtins["is_regex"] = typePredicate(object.REGEX_OBJ)
+	builtins["is_time"] = typePredicate(object.TIME_OBJ)
+	builtins["is_duration"] = typePredicate(object.DURATION_OBJ)
+	builtins["is_bytes"] = typePredicate(object.BYTES_OBJ)
+	builtins["is_null"] = typePredicate(object.NULL_OBJ)
+	builtins["is_function"] = typePredicate(object.FUNCTION_OBJ, object.BUILTIN_OBJ)
+}
+
+//type returns the name of the type of the value, the same name error messages use.
+func typeBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	return &object.String{Value: string(args[0].Type())}
+}
+
+//typePredicate makes the builtin telling if its argument is of one of the given types.
+func typePredicate(types ...object.ObjectType) *object.Builtin {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return wrongNumberOfArguments(len(args), 1, 1)
+		}
+		for _, t := range types {
+			if args[0].Type() == t {
+				return TRUE
+			}
+		}
+		return FALSE
+	}}
+}
+
+//int converts a string holding a whole number, or a boolean, to an integer. A float loses its fractional part.
+func intBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return arg
+	case *object.Float:
+		if math.IsNaN(arg.Value) || arg.Value >= math.MaxInt64 || arg.Value < math.MinInt64 {
+			return newError("cannot convert %s to INTEGER", arg.Inspect())
+		}
+		return &object.Integer{Value: int64(arg.Value)}
+	case *object.Boolean:
+		if arg.Value {
+			return &object.Integer{Value: 1}
+		}
+		return &object.Integer{Value: 0}
+	case *object.String:
+		value, err := strconv.ParseInt(arg.Value, 10, 64)
+		if err != nil {
+			return newError("cannot convert %s to INTEGER", repr(arg))
+		}
+		return &object.Integer{Value: value}
+	default:
+		return newError("cannot convert %s to INTEGER", arg.Type())
+	}
+}
+
+//float converts an integer or a string holding a number to a float.
+func floatBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Float:
+		return arg
+	case *object.Integer:
+		return &object.Float{Value: float64(arg.Value)}
+	case *object.String:
+		value, err := strconv.ParseFloat(arg.Value, 64)
+		if err != nil {
+			return newError("cannot convert %s to FLOAT", repr(arg))
+		}
+		return &object.Float{Value: value}
+	default:
+		return newError("cannot convert %s to FLOAT", arg.Type())
+	}
+}
+
+//str makes a string of any value, the same text puts would print for it. Bytes are read as UTF-8 instead.
+func strBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.String:
+		return arg
+	case *object.Bytes:
+		return bytesToString(arg)
+	default:
+		return &object.String{Value: arg.Inspect()}
+	}
+}
+
+//bool tells if a value counts as true in an if condition.
+func boolBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	return nativeBoolToBooleanObject(isTruthy(args[0]))
+}
+
+//repr returns the value written the way it would be in a program, so strings are quoted and frozen arrays and hashes are passed to freeze.
+func reprBuiltin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return wrongNumberOfArguments(len(args), 1, 1)
+	}
+	return &object.String{Value: repr(args[0])}
+}
+
+//unescapes are the characters a string literal needs a backslash for, the other way round from the lexer's escapes.
+var unescapes = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+func repr(obj object.Object) string {
+	return writeRepr(obj, make(map[object.Object]bool), false)
+}
+
+//writeRepr keeps the arrays, hashes and sets it is inside of in visiting, one that holds itself is written as '...' the second time.
+//Freezing is deep, so inside a frozen value the values in it don't need a freeze of their own.
+func writeRepr(obj object.Object, visiting map[object.Object]bool, frozen bool) string {
+	switch obj := obj.(type) {
+	case *object.String:
+		return `"` + unescapes.Replace(obj.Value) + `"`
+	case *object.Regex:
+		return "regex(" + writeRepr(&object.String{Value: obj.Value.String()}, visiting, frozen) + ")"
+	case *object.Time:
+		return `parse_time("` + obj.Inspect() + `")`
+	case *object.Duration:
+		return `duration("` + obj.Inspect() + `")`
+	case *object.Array, *object.Hash, *object.Set:
+	default:
+		return obj.Inspect()
+	}
+
+	if visiting[obj] {
+		return "..."
+	}
+	visiting[obj] = true
+	defer delete(visiting, obj)
+
+	elements := []string{}
+	switch obj := obj.(type) {
+	case *object.Array:
+		for _, el := range obj.Elements {
+			elements = append(elements, writeRepr(el, visiting, frozen || obj.Frozen))
+		}
+		return freezeRepr("["+strings.Join(elements, ", ")+"]", obj.Frozen && !frozen)
+	case *object.Hash:
+		for _, pair := range obj.Pairs() {
+			key := writeRepr(pair.Key, visiting, frozen || obj.Frozen)
+			elements = append(elements, key+": "+writeRepr(pair.Value, visiting, frozen || obj.Frozen))
+		}
+		return freezeRepr("{"+strings.Join(elements, ", ")+"}", obj.Frozen && !frozen)
+	default:
+		// a set can only hold frozen arrays, they are all frozen because they are in it
+		for _, el := range obj.(*object.Set).Elements() {
+			elements = append(elements, writeRepr(el, visiting, frozen))
+		}
+		return "#{" + strings.Join(elements, ", ") + "}"
+	}
+}
+
+func freezeRepr(literal string, frozen bool) string {
+	if frozen {
+		return "freeze(" + literal + ")"
+	}
+	return literal
+}
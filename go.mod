@@ -1,3 +1,3 @@
 module github.com/Neeraj-Natu/shifu
 
-go 1.12
+go 1.25
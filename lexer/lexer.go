@@ -56,7 +56,7 @@ func (l *Lexer) NextToken() token.Token {
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.AND, Literal: literal}
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = newToken(token.AMP, l.ch)
 		}
 	case '|':
 		if l.seekNextChar() == '|' {
@@ -64,6 +64,15 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.OR, Literal: literal}
+		} else {
+			tok = newToken(token.PIPE, l.ch)
+		}
+	case '#':
+		if l.seekNextChar() == '{' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.SET_BRACE, Literal: literal}
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
@@ -85,6 +94,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.COMMA, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		tok = newToken(token.DOT, l.ch)
 	case '{':
 		tok = newToken(token.LCBRACE, l.ch)
 	case '}':
@@ -101,8 +112,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isNumber(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Literal, tok.Type = l.readNumber()
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -129,31 +139,77 @@ func (l *Lexer) readChar() {
 	l.readPosition += 1
 }
 
+//readIdentifier reads a name, after its first letter it can have digits too, like 'sha256'.
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isNumber(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+//readNumber reads an integer, or a float when the digits are followed by a '.' and more digits or by an exponent like 'e+21'.
+//A '.' followed by anything else is left alone so that '1.len()' is still a method call.
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
+	tokenType := token.TokenType(token.INT)
 	for isNumber(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	if l.ch == '.' && isNumber(l.seekNextChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		for isNumber(l.ch) {
+			l.readChar()
+		}
+	}
+	if l.ch == 'e' || l.ch == 'E' {
+		//the exponent needs digits, after a sign or not, an 'e' without them isn't part of the number
+		digits := l.readPosition
+		if digits < len(l.input) && (l.input[digits] == '+' || l.input[digits] == '-') {
+			digits++
+		}
+		if digits < len(l.input) && isNumber(l.input[digits]) {
+			tokenType = token.FLOAT
+			for l.position < digits {
+				l.readChar()
+			}
+			for isNumber(l.ch) {
+				l.readChar()
+			}
+		}
+	}
+	return l.input[position:l.position], tokenType
 }
 
+//escapes are the characters that can follow a backslash in a string, and what the two of them stand for.
+var escapes = map[byte]byte{
+	'"':  '"',
+	'\\': '\\',
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+}
+
+//Reads a string up to the closing quote. A backslash followed by one of the escapes stands for that character,
+//before any other character the backslash is kept as it is.
 func (l *Lexer) readString() string {
-	position := l.position + 1
+	var out []byte
 	for {
 		l.readChar()
 		if l.ch == '"' || l.ch == 0 {
 			break
 		}
+		if l.ch == '\\' {
+			if escaped, ok := escapes[l.seekNextChar()]; ok {
+				l.readChar()
+				out = append(out, escaped)
+				continue
+			}
+		}
+		out = append(out, l.ch)
 	}
-	return l.input[position:l.position]
+	return string(out)
 }
 
 func isLetter(ch byte) bool {
@@ -7,7 +7,7 @@ import (
 )
 
 func TestNextToken(t *testing.T) {
-	input := `=+(){}[],;:`
+	input := `=+(){}[],;:.`
 
 	tests := []struct {
 		expectedType    token.TokenType
@@ -24,6 +24,7 @@ func TestNextToken(t *testing.T) {
 		{token.COMMA, ","},
 		{token.SEMICOLON, ";"},
 		{token.COLON, ":"},
+		{token.DOT, "."},
 		{token.EOF, ""},
 	}
 
@@ -213,6 +214,175 @@ func TestEdgeCaseToken(t *testing.T) {
 	}
 }
 
+func TestLoopToken(t *testing.T) {
+	input := `
+	for (x in xs) { total = total + x; }
+	while (i < 3) { i = i + 1; }
+	`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.VARIABLE, "x"},
+		{token.IN, "in"},
+		{token.VARIABLE, "xs"},
+		{token.RPAREN, ")"},
+		{token.LCBRACE, "{"},
+		{token.VARIABLE, "total"},
+		{token.ASSIGN, "="},
+		{token.VARIABLE, "total"},
+		{token.PLUS, "+"},
+		{token.VARIABLE, "x"},
+		{token.SEMICOLON, ";"},
+		{token.RCBRACE, "}"},
+		{token.WHILE, "while"},
+		{token.LPAREN, "("},
+		{token.VARIABLE, "i"},
+		{token.LT, "<"},
+		{token.INT, "3"},
+		{token.RPAREN, ")"},
+		{token.LCBRACE, "{"},
+		{token.VARIABLE, "i"},
+		{token.ASSIGN, "="},
+		{token.VARIABLE, "i"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.RCBRACE, "}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	input := `#{1} | a & b || c && d #`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.SET_BRACE, "#{"},
+		{token.INT, "1"},
+		{token.RCBRACE, "}"},
+		{token.PIPE, "|"},
+		{token.VARIABLE, "a"},
+		{token.AMP, "&"},
+		{token.VARIABLE, "b"},
+		{token.OR, "||"},
+		{token.VARIABLE, "c"},
+		{token.AND, "&&"},
+		{token.VARIABLE, "d"},
+		{token.ILLEGAL, "#"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+//A '.' only makes a float when digits follow it, otherwise it is still a dot.
+func TestFloatToken(t *testing.T) {
+	input := `1.5 10.25 1e21 1.5E-7 2e+3 3e 4e+ 1.len() 2.`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "1.5"},
+		{token.FLOAT, "10.25"},
+		{token.FLOAT, "1e21"},
+		{token.FLOAT, "1.5E-7"},
+		{token.FLOAT, "2e+3"},
+		{token.INT, "3"},
+		{token.VARIABLE, "e"},
+		{token.INT, "4"},
+		{token.VARIABLE, "e"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.DOT, "."},
+		{token.VARIABLE, "len"},
+		{token.LPAREN, "("},
+		{token.RPAREN, ")"},
+		{token.INT, "2"},
+		{token.DOT, "."},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestIdentifierWithDigitsToken(t *testing.T) {
+	input := `sha256 x1y 2x`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.VARIABLE, "sha256"},
+		{token.VARIABLE, "x1y"},
+		{token.INT, "2"},
+		{token.VARIABLE, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
 func TestStringToken(t *testing.T) {
 	input := `
 	"foobar"
@@ -256,3 +426,28 @@ func TestStringToken(t *testing.T) {
 		}
 	}
 }
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"say \"hi\""`, `say "hi"`},
+		{`"a\\b"`, `a\b`},
+		{`"line\nnext"`, "line\nnext"},
+		{`"tab\there"`, "tab\there"},
+		{`"cr\r"`, "cr\r"},
+		{`"keep \d"`, `keep \d`},
+		{`"end\\"`, `end\`},
+	}
+
+	for _, tt := range tests {
+		tok := New(tt.input).NextToken()
+		if tok.Type != token.STRING {
+			t.Fatalf("tokentype wrong for %s. expected=%q, got=%q", tt.input, token.STRING, tok.Type)
+		}
+		if tok.Literal != tt.expected {
+			t.Errorf("literal wrong for %s. expected=%q, got=%q", tt.input, tt.expected, tok.Literal)
+		}
+	}
+}
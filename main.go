@@ -6,14 +6,29 @@ import (
 	"os"
 	"os/user"
 
+	"github.com/Neeraj-Natu/shifu/evaluator"
 	"github.com/Neeraj-Natu/shifu/repl"
 )
 
 func main() {
 
 	outputPtr := flag.String("output", "lang", "output from lexer, parser or langauge itself")
+	filesPtr := flag.String("files", "", "directory scripts may read and write files in, they can't use files without it")
 	flag.Parse()
 
+	interpreter := evaluator.NewInterpreter(os.Stdout)
+	if *filesPtr != "" {
+		if err := interpreter.AllowFiles(*filesPtr); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot allow files in %s: %s\n", *filesPtr, err)
+			os.Exit(1)
+		}
+	}
+
+	//A script given after the flags is run instead of starting the REPL, the arguments after it are passed to the script.
+	if flag.NArg() > 0 {
+		os.Exit(runScript(interpreter, flag.Arg(0), flag.Args()[1:]))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -30,9 +45,35 @@ func main() {
 		repl.StartParser(os.Stdin, os.Stdout)
 	}
 	if *outputPtr == "lang" {
-		repl.StartLang(os.Stdin, os.Stdout)
+		defer interpreter.Close()
+		//The REPL reads the standard input itself, so it isn't shared with the lines typed in.
+		interpreter.AllowProcess(evaluator.Process{Args: []string{}, Env: os.LookupEnv, Exit: exitClosing(interpreter)})
+		repl.StartInterpreter(os.Stdin, os.Stdout, interpreter)
+	}
+
+}
+
+//runScript runs the script in the file at path and returns the status code the process should exit with.
+func runScript(interpreter *evaluator.Interpreter, path string, args []string) int {
+	defer interpreter.Close()
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read script: %s\n", err)
+		return 1
 	}
+	interpreter.AllowProcess(evaluator.Process{Args: args, Env: os.LookupEnv, Stdin: os.Stdin, Exit: exitClosing(interpreter)})
+	if !repl.RunScript(string(source), os.Stderr, interpreter) {
+		return 1
+	}
+	return 0
+}
 
+//exitClosing is the Exit given to scripts, os.Exit skips deferred calls so the interpreter is closed before it.
+func exitClosing(interpreter *evaluator.Interpreter) func(int) {
+	return func(code int) {
+		interpreter.Close()
+		os.Exit(code)
+	}
 }
 
 const SHIFU = `
@@ -3,23 +3,27 @@ package object
 //NewEnclosedEnvironment is used for extending the current environment, it creates a
 //new instance of object.Environment with a pointer to the environment it should extend.
 //With this we enclose a fresh and empty environment with an existing one.
+//The maps of an enclosed environment are only made once a variable is set by name, resolved programs never do that.
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
+	return &Environment{outer: outer}
 }
 
 //NewEnvironment is just what is used to create a newEnvironment instance.
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	c := make(map[string]bool)
+	return &Environment{store: s, constants: c, outer: nil}
 }
 
 //Environment is used to keep track of value by associating them with a name.
 //It's just a Hashmap that store the value and name of the variables.
+//constants remembers which of the names in store were declared with const.
+//slots hold the variables the resolver assigned a slot to, indexed by ast.Variable.Index.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store     map[string]Object
+	constants map[string]bool
+	slots     []Object
+	outer     *Environment
 }
 
 //Get the value of a variable if present in the Environment instance.
@@ -32,8 +36,87 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+//Assign updates a variable that already exists, in the environment that declared it.
+//Unlike Set it never creates a new binding, it reports false when the name isn't declared anywhere.
+func (e *Environment) Assign(name string, val Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = val
+		return val, true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+	return nil, false
+}
+
 //Set the variable in Environment for later use.
 func (e *Environment) Set(name string, val Object) Object {
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
 	e.store[name] = val
+	delete(e.constants, name)
+	return val
+}
+
+//SetConst is Set for bindings declared with const.
+func (e *Environment) SetConst(name string, val Object) Object {
+	e.Set(name, val)
+	if e.constants == nil {
+		e.constants = make(map[string]bool)
+	}
+	e.constants[name] = true
+	return val
+}
+
+//GetAt returns the value in slot index of the environment depth levels out from this one.
+//A slot that was never set, because its declaration hasn't been evaluated yet, is reported as missing.
+func (e *Environment) GetAt(depth, index int) (Object, bool) {
+	env := e.ancestor(depth)
+	if index >= len(env.slots) || env.slots[index] == nil {
+		return nil, false
+	}
+	return env.slots[index], true
+}
+
+//SetAt stores the value in slot index of this environment, growing the slots as needed.
+func (e *Environment) SetAt(index int, val Object) Object {
+	for index >= len(e.slots) {
+		e.slots = append(e.slots, nil)
+	}
+	e.slots[index] = val
 	return val
 }
+
+//AssignAt changes the value in a slot that has already been set, like Assign does for names.
+func (e *Environment) AssignAt(depth, index int, val Object) (Object, bool) {
+	if _, ok := e.GetAt(depth, index); !ok {
+		return nil, false
+	}
+	return e.ancestor(depth).SetAt(index, val), true
+}
+
+func (e *Environment) ancestor(depth int) *Environment {
+	env := e
+	for i := 0; i < depth; i++ {
+		env = env.outer
+	}
+	return env
+}
+
+//IsConst reports if the binding the name refers to, wherever it was declared, is a constant.
+func (e *Environment) IsConst(name string) bool {
+	if _, ok := e.store[name]; ok {
+		return e.constants[name]
+	}
+	if e.outer != nil {
+		return e.outer.IsConst(name)
+	}
+	return false
+}
+
+//IsDeclared reports if the name is declared in this environment itself, outer environments are not looked at.
+func (e *Environment) IsDeclared(name string) bool {
+	_, ok := e.store[name]
+	return ok
+}
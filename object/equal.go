@@ -0,0 +1,157 @@
+package object
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+//Equals tells if two values are the same, arrays and hashes are compared by what they hold instead of by reference.
+//Two hashes are equal when they have the same keys with equal values, the order the keys were added in doesn't matter.
+//Two sets are equal when they have the same elements, in any order.
+//An integer and a float are equal when the float is exactly that whole number, so 1 and 1.0 are the same key and element.
+//Two regexes are equal when they were made from the same pattern, two times when they are the same instant in any time zone.
+//Functions and builtins are only ever equal to themselves.
+func Equals(a, b Object) bool {
+	return equals(a, b, make(map[[2]Object]bool))
+}
+
+//comparing keeps the pairs of arrays and hashes that are being compared already,
+//a value that holds itself would otherwise be compared forever. Such a pair is taken to be equal
+//as far as it got, whatever else differs is found by the comparison that is still going on.
+func equals(a, b Object, comparing map[[2]Object]bool) bool {
+	//NaN isn't equal to itself, even when it's the same value
+	if a == b && a.Type() != FLOAT_OBJ {
+		return true
+	}
+	if a.Type() != b.Type() {
+		return integerEqualsFloat(a, b) || integerEqualsFloat(b, a)
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value == b.(*Integer).Value
+	case *Float:
+		return a.Value == b.(*Float).Value
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *Regex:
+		return a.Value.String() == b.(*Regex).Value.String()
+	case *Time:
+		return a.Value.Equal(b.(*Time).Value)
+	case *Duration:
+		return a.Value == b.(*Duration).Value
+	case *Bytes:
+		return bytes.Equal(a.Value, b.(*Bytes).Value)
+	case *Null:
+		return true
+	case *Array:
+		other := b.(*Array)
+		if len(a.Elements) != len(other.Elements) {
+			return false
+		}
+		pair := [2]Object{a, other}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		for i := range a.Elements {
+			if !equals(a.Elements[i], other.Elements[i], comparing) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		other := b.(*Hash)
+		if a.Len() != other.Len() {
+			return false
+		}
+		pair := [2]Object{a, other}
+		if comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		for _, p := range a.Pairs() {
+			found, ok := other.Get(p.Key.(Hashable))
+			if !ok || !equals(p.Value, found.Value, comparing) {
+				return false
+			}
+		}
+		return true
+	case *Set:
+		other := b.(*Set)
+		if a.Len() != other.Len() {
+			return false
+		}
+		for _, el := range a.Elements() {
+			if !other.Has(el.(Hashable)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func integerEqualsFloat(a, b Object) bool {
+	i, ok := a.(*Integer)
+	if !ok {
+		return false
+	}
+	f, ok := b.(*Float)
+	if !ok {
+		return false
+	}
+	value, ok := floatAsInteger(f.Value)
+	return ok && value == i.Value
+}
+
+//AsHashable returns the value as a Hashable when it can be used as a hash key.
+//Arrays implement Hashable but only frozen ones can be keys, they are tuples then and can't change
+//while they are in a hash. All of their elements have to be usable as keys as well.
+func AsHashable(obj Object) (Hashable, bool) {
+	if !hashable(obj, make(map[*Array]bool)) {
+		return nil, false
+	}
+	return obj.(Hashable), true
+}
+
+func hashable(obj Object, visiting map[*Array]bool) bool {
+	switch obj := obj.(type) {
+	case *Integer, *String, *Boolean:
+		return true
+	case *Float:
+		return !math.IsNaN(obj.Value)
+	case *Array:
+		// an array that holds itself has no HashKey
+		if !obj.Frozen || visiting[obj] {
+			return false
+		}
+		visiting[obj] = true
+		for _, el := range obj.Elements {
+			if !hashable(el, visiting) {
+				return false
+			}
+		}
+		delete(visiting, obj)
+		return true
+	}
+	return false
+}
+
+//HashKey of an array is built from the HashKeys of its elements, so equal arrays have equal HashKeys.
+//Only call it on arrays AsHashable accepts.
+func (a *Array) HashKey() HashKey {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	for _, el := range a.Elements {
+		key := el.(Hashable).HashKey()
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf, key.Value)
+		h.Write(buf)
+	}
+	return HashKey{Type: a.Type(), Value: h.Sum64()}
+}
@@ -2,9 +2,14 @@ package object
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Neeraj-Natu/shifu/ast"
 )
@@ -20,6 +25,7 @@ type Object interface {
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -29,6 +35,11 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	SET_OBJ          = "SET"
+	REGEX_OBJ        = "REGEX"
+	TIME_OBJ         = "TIME"
+	DURATION_OBJ     = "DURATION"
+	BYTES_OBJ        = "BYTES"
 )
 
 //Integer implements Object interface. Every ast.IntegerLiteral is converted to this Object.Integer
@@ -40,6 +51,30 @@ type Integer struct {
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+//Float implements the Object interface. Every ast.FloatLiteral is converted to this Object.Float
+//Inspect always writes a '.' or an exponent so a whole float doesn't look like an Integer.
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Inspect() string {
+	//infinities and NaN are written as the constants that stand for them, so they can be read back
+	switch {
+	case math.IsInf(f.Value, 1):
+		return "INF"
+	case math.IsInf(f.Value, -1):
+		return "-INF"
+	case math.IsNaN(f.Value):
+		return "NAN"
+	}
+	out := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(out, ".e") {
+		out += ".0"
+	}
+	return out
+}
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
 //Boolean implements the Object interface. Every ast.BooleanLiteral is converted to this Object.Boolean
 // When evaluating the language, the reference to this struct is then passed around.
 type Boolean struct {
@@ -58,6 +93,40 @@ type String struct {
 func (s *String) Inspect() string  { return s.Value }
 func (s *String) Type() ObjectType { return STRING_OBJ }
 
+//Regex implements the Object interface, it holds a compiled regular expression made by the 'regex' builtin.
+//Go's regexp runs in time linear to the input, so patterns from outside can't make a match run forever.
+type Regex struct {
+	Value *regexp.Regexp
+}
+
+func (r *Regex) Inspect() string  { return `regex("` + r.Value.String() + `")` }
+func (r *Regex) Type() ObjectType { return REGEX_OBJ }
+
+//Time implements the Object interface, it is an instant along with the time zone it's written in.
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Inspect() string  { return t.Value.Format(time.RFC3339Nano) }
+func (t *Time) Type() ObjectType { return TIME_OBJ }
+
+//Duration implements the Object interface, it is the time between two instants like '1h30m0s'.
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Inspect() string  { return d.Value.String() }
+func (d *Duration) Type() ObjectType { return DURATION_OBJ }
+
+//Bytes implements the Object interface, it holds binary data like a digest or decoded base64 that isn't always valid text.
+//Inspect writes it as the call to 'hex_decode' that makes it.
+type Bytes struct {
+	Value []byte
+}
+
+func (b *Bytes) Inspect() string  { return `hex_decode("` + hex.EncodeToString(b.Value) + `")` }
+func (b *Bytes) Type() ObjectType { return BYTES_OBJ }
+
 //Null implements the Object interface. This represents the abscene of value.
 type Null struct{}
 
@@ -74,23 +143,43 @@ func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 
 //Error is the object that is returned when an invalid syntax is used while writing programs in language.
 //this is different from exception handling that is done within a program written in the language.
+//Stack holds the names of the functions the error unwound through, innermost first.
 type Error struct {
 	Message string
+	Stack   []string
 }
 
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string {
+	var out bytes.Buffer
+
+	out.WriteString("ERROR: " + e.Message)
+	for _, frame := range e.Stack {
+		out.WriteString("\n\tat " + frame)
+	}
+	return out.String()
+}
 
 //Function implements the Object interface. Every ast.FunctionLiteral is converted to this Object.Function
 //while evaluating functions in the language, reference to this struct is then passed on.
 //Also any variables are all stored in the environment
+//Name is empty for anonymous functions, declared functions carry it for error messages and stack traces.
 type Function struct {
+	Name       string
 	Parameters []*ast.Variable
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+
+//FrameName is how the function shows up in error messages and stack traces.
+func (f *Function) FrameName() string {
+	if f.Name == "" {
+		return "anonymous function"
+	}
+	return f.Name
+}
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
@@ -100,6 +189,9 @@ func (f *Function) Inspect() string {
 	}
 
 	out.WriteString("func")
+	if f.Name != "" {
+		out.WriteString(" " + f.Name)
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") {\n")
@@ -122,25 +214,84 @@ func (b *Builtin) Inspect() string  { return "builtin function" }
 
 //Array implements the Object interface. Every ast.ArrayLiteral is converted to this Object.Array
 //while evaluating Arrays in the language, reference to this struct is then passed on.
+//A Frozen array can't be changed in place anymore, see the freeze builtin.
+//
+//Push and Pop make new arrays that can share their Elements with the array they were made from,
+//which is what makes building up an array one push at a time cheap. Elements must therefore only be
+//changed through SetElement, which copies them first when they are shared.
 type Array struct {
 	Elements []Object
+	Frozen   bool
+	backing  *arrayBacking
 }
 
-func (a *Array) Type() ObjectType { return ARRAY_OBJ }
-func (a *Array) Inspect() string {
-	var out bytes.Buffer
+//arrayBacking is what all the arrays sharing the same underlying Go array know about it.
+type arrayBacking struct {
+	used   int  // slots of the underlying array some Array has elements in, the rest are free to push into
+	shared bool // more than one Array uses it, so it must not be written to
+}
 
-	elements := []string{}
-	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
+//backingStore returns the arrayBacking of the array. Arrays made with a literal don't share their Elements with anyone yet.
+func (a *Array) backingStore() *arrayBacking {
+	if a.backing == nil {
+		a.backing = &arrayBacking{used: len(a.Elements)}
+	}
+	return a.backing
+}
+
+//Push returns a new array with the element added at the end, the array itself stays as it is.
+//When nothing was pushed onto the array before, the new element goes into the spare capacity of
+//its Elements and both arrays share them, otherwise the elements are copied into room for twice as many.
+func (a *Array) Push(el Object) *Array {
+	b := a.backingStore()
+	n := len(a.Elements)
+	if n == b.used && n < cap(a.Elements) {
+		b.used++
+		b.shared = true
+		return &Array{Elements: append(a.Elements, el), backing: b}
 	}
 
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-	return out.String()
+	size := 2 * (n + 1)
+	if size < 4 {
+		size = 4
+	}
+	elements := make([]Object, n+1, size)
+	copy(elements, a.Elements)
+	elements[n] = el
+	return &Array{Elements: elements, backing: &arrayBacking{used: n + 1}}
+}
+
+//Pop returns a new array without the element at index i, the array itself stays as it is.
+//Taking off the last element shares the Elements, any other one means copying the rest.
+func (a *Array) Pop(i int) *Array {
+	n := len(a.Elements)
+	if i == n-1 {
+		b := a.backingStore()
+		b.shared = true
+		return &Array{Elements: a.Elements[:i], backing: b}
+	}
+
+	elements := make([]Object, 0, n-1)
+	elements = append(elements, a.Elements[:i]...)
+	elements = append(elements, a.Elements[i+1:]...)
+	return &Array{Elements: elements}
 }
 
+//SetElement changes the element at index i in place. If the Elements are shared with other arrays
+//this array gets its own copy of them first, so the others don't see the change.
+func (a *Array) SetElement(i int, val Object) {
+	if a.backing != nil && a.backing.shared {
+		elements := make([]Object, len(a.Elements))
+		copy(elements, a.Elements)
+		a.Elements = elements
+		a.backing = nil
+	}
+	a.Elements[i] = val
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string  { return inspect(a, make(map[Object]bool)) }
+
 //HashKey is the hashkey that stores the hashed value of the keys for HashLiterals.
 //this is used to find if and HashLiteral has a key and returns the value that is stored against that Key in the hashLiteral.
 //HashKey can have keys as string, integers or booleans so has differnt HashKey() methods for each of the LiteralType to compare keys.
@@ -165,14 +316,35 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
-func (s *String) HashKey() HashKey {
+//A whole float has the HashKey of its integer since the two are equal, so 1.0 finds the value stored for 1.
+//NaN isn't equal to anything, not even itself, so AsHashable doesn't accept it as a key.
+func (f *Float) HashKey() HashKey {
+	if i, ok := floatAsInteger(f.Value); ok {
+		return (&Integer{Value: i}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+//floatAsInteger returns the integer a float is equal to, when it's whole and not too big for an INTEGER.
+func floatAsInteger(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+//hashString is what String.HashKey uses to hash the string, the tests swap it for a weak one to force collisions.
+var hashString = func(s string) uint64 {
 	h := fnv.New64a()
-	h.Write([]byte(s.Value))
+	h.Write([]byte(s))
+	return h.Sum64()
+}
 
-	return HashKey{Type: s.Type(), Value: h.Sum64()}
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Value: hashString(s.Value)}
 }
 
-// Type of Values in the Hash.Pairs which basically can be anything.
+// Type of Values in the Hash which basically can be anything.
 type HashPair struct {
 	Key   Object
 	Value Object
@@ -182,31 +354,148 @@ type HashPair struct {
 // It can have Strings, Boolean or Integers as keys which have differnt implementations used to define their own HashKeys
 // Those HashKeys are used to ascertain if the keys are similar or different and to compare two hashes or values in hashes.
 // Also the HashKeys are used to locate and take out the values from Hashes just like any Map.
-// As in any other language Hashes are prone to have hash collision in shifu as well.
+// As in any other language Hashes are prone to have hash collision in shifu as well, so every HashKey points to a bucket
+// of pairs and the keys in a bucket are compared to each other to find the right one.
+// Pairs are kept in the order their keys were first added, so inspecting or iterating a hash always gives the same order.
+// A Frozen hash can't be changed in place anymore, see the freeze builtin.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	pairs  []HashPair
+	index  map[HashKey][]int // positions in pairs of all the keys with this HashKey
+	Frozen bool
+}
+
+//NewHash creates an empty Hash.
+func NewHash() *Hash {
+	return &Hash{pairs: []HashPair{}, index: make(map[HashKey][]int)}
 }
 
 func (h *Hash) Type() ObjectType {
 	return HASH_OBJ
 }
 
-func (h *Hash) Inspect() string {
-	var out bytes.Buffer
+//Get returns the pair stored for the key, if there is one.
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+	i := h.find(key, key.HashKey())
+	if i < 0 {
+		return HashPair{}, false
+	}
+	return h.pairs[i], true
+}
 
-	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s",
-			pair.Key.Inspect(), pair.Value.Inspect()))
+//find returns the position of the key in pairs, or -1 when the hash doesn't have it.
+func (h *Hash) find(key Hashable, hashed HashKey) int {
+	for _, i := range h.index[hashed] {
+		if Equals(h.pairs[i].Key, key) {
+			return i
+		}
 	}
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+	return -1
+}
 
-	return out.String()
+//Set stores the value for the key. A key that is already in the hash keeps its place in the order.
+func (h *Hash) Set(key Hashable, value Object) {
+	hashed := key.HashKey()
+	if i := h.find(key, hashed); i >= 0 {
+		h.pairs[i].Value = value
+		return
+	}
+	h.index[hashed] = append(h.index[hashed], len(h.pairs))
+	h.pairs = append(h.pairs, HashPair{Key: key, Value: value})
+}
+
+//Pairs returns all the pairs of the hash in insertion order. The slice must not be modified.
+func (h *Hash) Pairs() []HashPair {
+	return h.pairs
+}
+
+//Len returns the number of pairs in the hash.
+func (h *Hash) Len() int {
+	return len(h.pairs)
+}
+
+func (h *Hash) Inspect() string { return inspect(h, make(map[Object]bool)) }
+
+//Set is a collection of distinct values, it can hold anything that can be a key of a Hash.
+//Like a Hash it keeps its elements in the order they were first added.
+//There is no way to change a set in place, adding to it or taking elements away always makes a new one.
+type Set struct {
+	elements *Hash
+}
+
+//NewSet creates an empty Set.
+func NewSet() *Set {
+	return &Set{elements: NewHash()}
+}
+
+func (s *Set) Type() ObjectType { return SET_OBJ }
+
+//Add puts the element into the set, adding an element that is in the set already does nothing.
+func (s *Set) Add(el Hashable) {
+	if _, ok := s.elements.Get(el); !ok {
+		s.elements.Set(el, el)
+	}
+}
+
+//Has tells if the element is in the set.
+func (s *Set) Has(el Hashable) bool {
+	_, ok := s.elements.Get(el)
+	return ok
+}
+
+//Elements returns the elements of the set in the order they were added.
+func (s *Set) Elements() []Object {
+	elements := make([]Object, s.elements.Len())
+	for i, pair := range s.elements.Pairs() {
+		elements[i] = pair.Key
+	}
+	return elements
+}
+
+//Len returns the number of elements in the set.
+func (s *Set) Len() int {
+	return s.elements.Len()
+}
+
+func (s *Set) Inspect() string { return inspect(s, make(map[Object]bool)) }
+
+//inspect writes arrays, hashes and sets keeping the ones it is inside of in visiting,
+//since index assignment lets an array or hash hold itself one that comes up again is written as '...'.
+func inspect(obj Object, visiting map[Object]bool) string {
+	switch obj.(type) {
+	case *Array, *Hash, *Set:
+	default:
+		return obj.Inspect()
+	}
+
+	if visiting[obj] {
+		return "..."
+	}
+	visiting[obj] = true
+	defer delete(visiting, obj)
+
+	elements := []string{}
+	switch obj := obj.(type) {
+	case *Array:
+		for _, e := range obj.Elements {
+			elements = append(elements, inspect(e, visiting))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *Hash:
+		for _, pair := range obj.pairs {
+			elements = append(elements, inspect(pair.Key, visiting)+": "+inspect(pair.Value, visiting))
+		}
+		return "{" + strings.Join(elements, ", ") + "}"
+	default:
+		for _, el := range obj.(*Set).Elements() {
+			elements = append(elements, inspect(el, visiting))
+		}
+		return "#{" + strings.Join(elements, ", ") + "}"
+	}
 }
 
-//This interface is used in evaluator to check if a given object is usable as a hash key
+//This interface is used in evaluator to check if a given object is usable as a hash key,
+//use AsHashable for that as not every Hashable value can be a key all the time.
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
@@ -58,3 +58,131 @@ func TestIntegerHashKey(t *testing.T) {
 		t.Errorf("integers with twoerent content have same hash keys")
 	}
 }
+
+func TestHashCollisions(t *testing.T) {
+	// with a hash this weak every string collides with every other string of the same length
+	defer func(original func(string) uint64) { hashString = original }(hashString)
+	hashString = func(s string) uint64 { return uint64(len(s)) }
+
+	a := &String{Value: "ab"}
+	b := &String{Value: "ba"}
+	if a.HashKey() != b.HashKey() {
+		t.Fatalf("weak hash didn't make the keys collide")
+	}
+
+	h := NewHash()
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+	h.Set(&String{Value: "c"}, &Integer{Value: 3})
+	h.Set(&String{Value: "ab"}, &Integer{Value: 4})
+
+	if h.Len() != 3 {
+		t.Fatalf("hash has wrong number of pairs. expected=3, got=%d", h.Len())
+	}
+
+	tests := []struct {
+		key      string
+		expected int64
+	}{
+		{"ab", 4},
+		{"ba", 2},
+		{"c", 3},
+	}
+	for _, tt := range tests {
+		pair, ok := h.Get(&String{Value: tt.key})
+		if !ok {
+			t.Errorf("no pair for key %q", tt.key)
+			continue
+		}
+		if pair.Value.(*Integer).Value != tt.expected {
+			t.Errorf("wrong value for key %q. expected=%d, got=%s", tt.key, tt.expected, pair.Value.Inspect())
+		}
+	}
+
+	if _, ok := h.Get(&String{Value: "zz"}); ok {
+		t.Errorf("found a pair for a key that was never set")
+	}
+
+	if h.Inspect() != "{ab: 4, ba: 2, c: 3}" {
+		t.Errorf("wrong Inspect. got=%s", h.Inspect())
+	}
+}
+
+func TestHashKeysOfDifferentTypes(t *testing.T) {
+	h := NewHash()
+	h.Set(&Integer{Value: 1}, &String{Value: "int"})
+	h.Set(&Boolean{Value: true}, &String{Value: "bool"})
+
+	if h.Len() != 2 {
+		t.Fatalf("hash has wrong number of pairs. expected=2, got=%d", h.Len())
+	}
+	pair, _ := h.Get(&Integer{Value: 1})
+	if pair.Value.Inspect() != "int" {
+		t.Errorf("wrong value for 1. got=%s", pair.Value.Inspect())
+	}
+}
+
+func TestArrayHashKey(t *testing.T) {
+	tuple1 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}, Frozen: true}
+	tuple2 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}, Frozen: true}
+	other := &Array{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}, Frozen: true}
+
+	if tuple1.HashKey() != tuple2.HashKey() {
+		t.Errorf("arrays with same content have different hash keys")
+	}
+
+	if tuple1.HashKey() == other.HashKey() {
+		t.Errorf("arrays with different content have same hash keys")
+	}
+
+	if _, ok := AsHashable(&Array{Elements: []Object{&Integer{Value: 1}}}); ok {
+		t.Errorf("array that isn't frozen is usable as hash key")
+	}
+
+	if _, ok := AsHashable(tuple1); !ok {
+		t.Errorf("frozen array isn't usable as hash key")
+	}
+}
+
+func TestArrayPushSharesElements(t *testing.T) {
+	a := (&Array{Elements: []Object{}}).Push(&Integer{Value: 1})
+	b := a.Push(&Integer{Value: 2})
+	c := a.Push(&Integer{Value: 3})
+
+	if &b.Elements[0] != &a.Elements[0] {
+		t.Errorf("first push onto a didn't share its elements")
+	}
+	if &c.Elements[0] == &a.Elements[0] {
+		t.Errorf("second push onto a shares its elements, it would overwrite b")
+	}
+	if b.Inspect() != "[1, 2]" || c.Inspect() != "[1, 3]" {
+		t.Errorf("wrong arrays after pushing. got b=%s, c=%s", b.Inspect(), c.Inspect())
+	}
+
+	b.SetElement(0, &Integer{Value: 9})
+	if a.Inspect() != "[1]" || b.Inspect() != "[9, 2]" {
+		t.Errorf("setting an element of a shared array changed another one. got a=%s, b=%s", a.Inspect(), b.Inspect())
+	}
+}
+
+func TestInspectSelfReference(t *testing.T) {
+	arr := &Array{Elements: []Object{&Integer{Value: 1}}}
+	arr.SetElement(0, arr)
+	if arr.Inspect() != "[...]" {
+		t.Errorf("wrong inspect of an array holding itself. got=%s", arr.Inspect())
+	}
+
+	hash := NewHash()
+	hash.Set(&String{Value: "self"}, hash)
+	hash.Set(&String{Value: "items"}, &Array{Elements: []Object{hash, &Integer{Value: 2}}})
+	if hash.Inspect() != "{self: ..., items: [..., 2]}" {
+		t.Errorf("wrong inspect of a hash holding itself. got=%s", hash.Inspect())
+	}
+
+	//the same array twice side by side isn't a cycle
+	inner := &Array{Elements: []Object{&Integer{Value: 1}}}
+	outer := &Array{Elements: []Object{inner, inner}}
+	if outer.Inspect() != "[[1], [1]]" {
+		t.Errorf("wrong inspect of an array holding another one twice. got=%s", outer.Inspect())
+	}
+}
@@ -30,6 +30,8 @@ const (
 	LOWEST
 	EQUALS      // ==
 	LESSGREATER // > or <
+	UNION       // |
+	INTERSECT   // &
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
@@ -45,12 +47,16 @@ var precedences = map[token.TokenType]int{
 	token.GT:       LESSGREATER,
 	token.AND:      LESSGREATER,
 	token.OR:       LESSGREATER,
+	token.IN:       LESSGREATER,
+	token.PIPE:     UNION,
+	token.AMP:      INTERSECT,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.SLASH:    PRODUCT,
 	token.ASTERISK: PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACE:   INDEX,
+	token.DOT:      INDEX,
 }
 
 // Parser has three fields, l is a pointer to an instance of lexer on which we repeatedly call NextToken() to get next token input.
@@ -81,12 +87,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.VARIABLE, p.parseVariable)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.STRING, p.parseString)
 	p.registerPrefix(token.LCBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.SET_BRACE, p.parseSetLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -99,6 +107,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parseInfixExpression)
+	p.registerInfix(token.AMP, p.parseInfixExpression)
 
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
@@ -106,6 +117,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LBRACE, p.parseArrayLiteral)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACE, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -195,8 +207,19 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
 		return p.parseLetStatement()
+	case token.CONST:
+		return p.parseConstStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.FUNCTION:
+		if p.peekTokenIs(token.VARIABLE) {
+			return p.parseFunctionStatement()
+		}
+		return p.parseExpressionStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -225,6 +248,29 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmnt
 }
 
+// Function to parse const statements
+func (p *Parser) parseConstStatement() *ast.ConstStatement {
+	stmnt := &ast.ConstStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.VARIABLE) {
+		return nil
+	}
+	stmnt.Name = &ast.Variable{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmnt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmnt
+}
+
 // Function to parse return statements
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
@@ -239,10 +285,95 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 }
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+// An expression followed by '=' turns out to be the target of an assignment.
+func (p *Parser) parseExpressionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.ASSIGN) {
+		return p.parseAssignStatement(stmt.Expression)
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// Function to parse assignments, the target has already been parsed as an expression.
+func (p *Parser) parseAssignStatement(target ast.Expression) ast.Statement {
+	p.nextToken()
+	stmt := &ast.AssignStatement{Token: p.curToken, Target: target}
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	switch target.(type) {
+	case *ast.Variable, *ast.IndexExpression, *ast.MemberExpression:
+		return stmt
+	default:
+		msg := fmt.Sprintf("cannot assign to %s", target.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
+// Function to parse while loops, 'while (<condition>) { <statements> }'.
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LCBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// Function to parse for loops, 'for (<variable> in <expression>) { <statements> }'.
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.VARIABLE) {
+		return nil
+	}
+	stmt.Variable = &ast.Variable{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.nextToken()
+
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LCBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -316,6 +447,19 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("Could not parse %q as float", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	lit.Value = value
+	return lit
+}
+
 // Parsing function for Prefix Expressions
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
@@ -424,6 +568,32 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
+//This is the parsing function for named function declarations, 'func <name>(<parameters>) <block statement>'.
+func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	stmt := &ast.FunctionStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Name = &ast.Variable{Token: p.curToken, Value: p.curToken.Literal}
+
+	lit := &ast.FunctionLiteral{Token: stmt.Token, Name: stmt.Name.Value}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+
+	if !p.expectPeek(token.LCBRACE) {
+		return nil
+	}
+	lit.Body = p.parseBlockStatement()
+	stmt.Function = lit
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 // This is the parsing function for Function parameters
 func (p *Parser) parseFunctionParameters() []*ast.Variable {
 	variables := []*ast.Variable{}
@@ -489,6 +659,12 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 	return array
 }
 
+func (p *Parser) parseSetLiteral() ast.Expression {
+	set := &ast.SetLiteral{Token: p.curToken}
+	set.Elements = p.parseExpressionList(token.RCBRACE)
+	return set
+}
+
 //This is to parse the expression list in arrays. Also it can be used to parse the list in arguments of a function.
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
@@ -512,10 +688,48 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return list
 }
 
+// Parsing function for 'object.property', the property has to be a name.
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: left}
+	if !p.expectPeek(token.VARIABLE) {
+		return nil
+	}
+	exp.Property = &ast.Variable{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+// Parsing function for 'left[index]', a ':' inside the brackets makes it a slice instead.
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	tok := p.curToken
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+
+	var start ast.Expression
+	if !p.curTokenIs(token.COLON) {
+		start = p.parseExpression(LOWEST)
+		if !p.peekTokenIs(token.COLON) {
+			if !p.expectPeek(token.RBRACE) {
+				return nil
+			}
+			return &ast.IndexExpression{Token: tok, Left: left, Index: start}
+		}
+		p.nextToken()
+	}
+	return p.parseSliceExpression(&ast.SliceExpression{Token: tok, Left: left, Start: start})
+}
+
+// Parses the rest of 'left[start:end:step]' from the first ':' on, end and step can be left out.
+func (p *Parser) parseSliceExpression(exp *ast.SliceExpression) ast.Expression {
+	if !p.peekTokenIs(token.COLON) && !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if !p.peekTokenIs(token.RBRACE) {
+			p.nextToken()
+			exp.Step = p.parseExpression(LOWEST)
+		}
+	}
 	if !p.expectPeek(token.RBRACE) {
 		return nil
 	}
@@ -524,7 +738,7 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash.Pairs = []ast.HashLiteralPair{}
 	for !p.peekTokenIs(token.RCBRACE) {
 		p.nextToken()
 		key := p.parseExpression(LOWEST)
@@ -537,7 +751,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 		value := p.parseExpression(LOWEST)
 
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashLiteralPair{Key: key, Value: value})
 
 		if !p.peekTokenIs(token.RCBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
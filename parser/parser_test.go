@@ -144,6 +144,36 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := `2.5`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements doesnot contain a statement. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 2.5 {
+		t.Errorf("literal.Value not %g. got=%g", 2.5, literal.Value)
+	}
+	if literal.TokenLiteral() != "2.5" {
+		t.Errorf("literal.TokenLiteral() not %s. got=%s", "2.5", literal.TokenLiteral())
+	}
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	input := `"hello World!!"`
 	l := lexer.New(input)
@@ -275,6 +305,123 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingMemberExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"h.name", "(h.name)"},
+		{"h.a.b", "((h.a).b)"},
+		{"xs.push(1)", "(xs.push)(1)"},
+		{"h.a[0]", "((h.a)[0])"},
+		{"xs[0].b", "((xs[0]).b)"},
+		{"-h.count", "(-(h.count))"},
+		{"a.len() + 1", "((a.len)() + 1)"},
+		{`"abc".upper()`, "(abc.upper)()"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	l := lexer.New("h.name")
+	p := New(l)
+	program := p.ParseProgram()
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	member, ok := stmt.Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("exp not ast.MemberExpression. got=%T", stmt.Expression)
+	}
+	testVariable(t, member.Object, "h")
+	if member.Property.Value != "name" {
+		t.Errorf("member.Property.Value not 'name'. got=%s", member.Property.Value)
+	}
+}
+
+func TestMemberExpressionNeedsName(t *testing.T) {
+	l := lexer.New("h.1")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 || errors[0] != "expected next token to be VAR, got INTEGER instead" {
+		t.Errorf("wrong parser errors. got=%v", errors)
+	}
+}
+
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs[1:3]", "(xs[1:3])"},
+		{"xs[:-1]", "(xs[:(-1)])"},
+		{"xs[2:]", "(xs[2:])"},
+		{"xs[:]", "(xs[:])"},
+		{"xs[::2]", "(xs[::2])"},
+		{"xs[1:a + 1:-1]", "(xs[1:(a + 1):(-1)])"},
+		{"xs[1:2:]", "(xs[1:2])"},
+		{"xs[i][1:]", "((xs[i])[1:])"},
+		{"f(xs[1:])", "f((xs[1:]))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	l := lexer.New("xs[:2]")
+	p := New(l)
+	program := p.ParseProgram()
+	slice, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.SliceExpression)
+	if !ok {
+		t.Fatalf("exp not ast.SliceExpression. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if slice.Start != nil || slice.Step != nil {
+		t.Errorf("left out parts of the slice are not nil. got start=%v, step=%v", slice.Start, slice.Step)
+	}
+	testIntegerLiteral(t, slice.End, 2)
+}
+
+func TestParsingSetLiterals(t *testing.T) {
+	l := lexer.New("#{1, 2 * 2, a}; #{}")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	set, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.SetLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.SetLiteral. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if len(set.Elements) != 3 {
+		t.Fatalf("len(set.Elements) not 3. got=%d", len(set.Elements))
+	}
+	testIntegerLiteral(t, set.Elements[0], 1)
+	testInfixExpression(t, set.Elements[1], 2, "*", 2)
+	testVariable(t, set.Elements[2], "a")
+
+	empty := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.SetLiteral)
+	if len(empty.Elements) != 0 {
+		t.Errorf("len(empty.Elements) not 0. got=%d", len(empty.Elements))
+	}
+	if set.String() != "#{1, (2 * 2), a}" {
+		t.Errorf("set.String() wrong. got=%q", set.String())
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -344,6 +491,7 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"false == false", false, "==", false},
 		{"true && true", true, "&&", true},
 		{"true || true", true, "||", true},
+		{"foobar in barfoo", "foobar", "in", "barfoo"},
 	}
 	for _, tt := range infixTests {
 		l := lexer.New(tt.input)
@@ -375,6 +523,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b",
 			"((-a) * b)",
 		},
+		{
+			"a + b in c == d",
+			"(((a + b) in c) == d)",
+		},
+		{
+			"a | b & c == d",
+			"((a | (b & c)) == d)",
+		},
+		{
+			"a - b | c",
+			"((a - b) | c)",
+		},
 		{
 			"!-a",
 			"(!(-a))",
@@ -565,6 +725,205 @@ func TestIfElseExpressionParsing(t *testing.T) {
 	}
 }
 
+func TestConstStatements(t *testing.T) {
+	input := `
+	const x = 5;
+	const y = true
+	`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	tests := []struct {
+		expectedName  string
+		expectedValue interface{}
+	}{
+		{"x", 5},
+		{"y", true},
+	}
+
+	for i, tt := range tests {
+		stmt, ok := program.Statements[i].(*ast.ConstStatement)
+		if !ok {
+			t.Fatalf("program.Statements[%d] is not ast.ConstStatement. got=%T", i, program.Statements[i])
+		}
+		if !testVariable(t, stmt.Name, tt.expectedName) {
+			return
+		}
+		if !testLiteralExpression(t, stmt.Value, tt.expectedValue) {
+			return
+		}
+	}
+
+	if program.String() != "const x = 5;const y = true;" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestAssignStatementParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedTarget string
+		expectedValue  interface{}
+	}{
+		{"x = 5;", "x", 5},
+		{"y = true", "y", true},
+		{"foobar = y;", "foobar", "y"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.AssignStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.AssignStatement. got=%T", program.Statements[0])
+		}
+
+		if !testVariable(t, stmt.Target, tt.expectedTarget) {
+			return
+		}
+		if !testLiteralExpression(t, stmt.Value, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestIndexAssignmentParsing(t *testing.T) {
+	l := lexer.New("xs[1 + 1] = 5;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.AssignStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.AssignStatement. got=%T", program.Statements[0])
+	}
+
+	target, ok := stmt.Target.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("stmt.Target is not ast.IndexExpression. got=%T", stmt.Target)
+	}
+	if !testVariable(t, target.Left, "xs") {
+		return
+	}
+	if !testInfixExpression(t, target.Index, 1, "+", 1) {
+		return
+	}
+	testLiteralExpression(t, stmt.Value, 5)
+}
+
+func TestMemberAssignmentParsing(t *testing.T) {
+	l := lexer.New("config.retries = 5;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.AssignStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.AssignStatement. got=%T", program.Statements[0])
+	}
+
+	target, ok := stmt.Target.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("stmt.Target is not ast.MemberExpression. got=%T", stmt.Target)
+	}
+	if !testVariable(t, target.Object, "config") {
+		return
+	}
+	if target.Property.Value != "retries" {
+		t.Errorf("target.Property.Value not 'retries'. got=%s", target.Property.Value)
+	}
+	testLiteralExpression(t, stmt.Value, 5)
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	l := lexer.New("5 = 6;")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("parser has wrong number of errors. expected 1, got=%d (%v)", len(errors), errors)
+	}
+	if errors[0] != "cannot assign to 5" {
+		t.Errorf("wrong parser error. got=%q", errors[0])
+	}
+}
+
+func TestWhileStatementParsing(t *testing.T) {
+	input := `while (x < y) { x = x + 1; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d /n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statement[0] is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("Body is not 1 statements. got=%d\n", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[0].(*ast.AssignStatement); !ok {
+		t.Fatalf("Statements[0] is not ast.AssignStatement. got=%T", stmt.Body.Statements[0])
+	}
+}
+
+func TestForStatementParsing(t *testing.T) {
+	input := `for (x in [1, 2]) { puts(x); }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d /n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statement[0] is not ast.ForStatement. got=%T", program.Statements[0])
+	}
+
+	if !testVariable(t, stmt.Variable, "x") {
+		return
+	}
+
+	if _, ok := stmt.Iterable.(*ast.ArrayLiteral); !ok {
+		t.Fatalf("stmt.Iterable is not ast.ArrayLiteral. got=%T", stmt.Iterable)
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("Body is not 1 statements. got=%d\n", len(stmt.Body.Statements))
+	}
+
+	if stmt.String() != "for(x in [1, 2]) puts(x)" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `func(x,y) { x + y;}`
 
@@ -607,6 +966,43 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	testInfixExpression(t, bodyStmnt.Expression, "x", "+", "y")
 }
 
+func TestFunctionStatementParsing(t *testing.T) {
+	input := `func add(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d /n", 1, len(program.Statements))
+	}
+
+	stmnt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("program.Statement[0] is not ast.FunctionStatement. got=%T", program.Statements[0])
+	}
+
+	if !testVariable(t, stmnt.Name, "add") {
+		return
+	}
+
+	if stmnt.Function.Name != "add" {
+		t.Errorf("function Literal name is wrong. wanted add, got=%q", stmnt.Function.Name)
+	}
+
+	if len(stmnt.Function.Parameters) != 2 {
+		t.Fatalf("function Literal parameters are wrong. wanted 2, got=%d\n", len(stmnt.Function.Parameters))
+	}
+
+	testLiteralExpression(t, stmnt.Function.Parameters[0], "x")
+	testLiteralExpression(t, stmnt.Function.Parameters[1], "y")
+
+	if stmnt.String() != "func add(x,y)(x + y)" {
+		t.Errorf("stmnt.String() wrong. got=%q", stmnt.String())
+	}
+}
+
 func TestFunctionParameterParsing(t *testing.T) {
 	tests := []struct {
 		input          string
@@ -751,7 +1147,8 @@ func TestParsingHashLiteralStringKeys(t *testing.T) {
 		"three": 3,
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -807,7 +1204,8 @@ func TestParsingHashLiteralsIntegerKeys(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		integer, ok := key.(*ast.IntegerLiteral)
 		if !ok {
 			t.Errorf("key is not ast.IntegerLiteral. got=%T", key)
@@ -843,7 +1241,8 @@ func TestParsingHashLiteralsBooleanKeys(t *testing.T) {
 		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		boolean, ok := key.(*ast.Boolean)
 		if !ok {
 			t.Errorf("key is not ast.BooleanLiteral. got=%T", key)
@@ -885,7 +1284,8 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
@@ -10,6 +10,7 @@ import (
 	"github.com/Neeraj-Natu/shifu/lexer"
 	"github.com/Neeraj-Natu/shifu/object"
 	"github.com/Neeraj-Natu/shifu/parser"
+	"github.com/Neeraj-Natu/shifu/resolver"
 	"github.com/Neeraj-Natu/shifu/token"
 )
 
@@ -17,9 +18,13 @@ import (
 read from the input source until encountering a newline,
 take the just read line and pass it to an instance of our
 lexer and that to our Parser once the parser is done with
-it's job. we pass the AST into the evaluator which evaluates
+it's job. The resolver then checks the AST for mistakes that
+can be found without running it, like assigning to a constant
+or using a variable that was never declared, and decides where
+every local variable is going to be stored.
+we pass the AST into the evaluator which evaluates
 the whole program represented by the AST. After all this
-we print out the parsing errors if any or the evaluation
+we print out the parsing or resolving errors if any or the evaluation
 result calling Inspect() method on Program that recursively
 calls the Inspect() method on all of the statements belonging
 to that program.
@@ -28,10 +33,15 @@ to that program.
 const PROMPT = ">> "
 
 func StartLang(in io.Reader, out io.Writer) {
+	StartInterpreter(in, out, evaluator.NewInterpreter(out))
+}
+
+//StartInterpreter runs the lines read from in with an interpreter the host has set up, like one allowed to use files.
+func StartInterpreter(in io.Reader, out io.Writer, interpreter *evaluator.Interpreter) {
 	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	r := resolver.New(evaluator.BuiltinNames()...)
 	for {
-		fmt.Printf(PROMPT)
+		fmt.Fprint(out, PROMPT)
 		scanned := scanner.Scan()
 		if !scanned {
 			return
@@ -47,14 +57,22 @@ func StartLang(in io.Reader, out io.Writer) {
 		p := parser.New(l)
 		program := p.ParseProgram()
 		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+			printErrors(out, "parser", p.Errors())
+			continue
+		}
+		r.Resolve(program)
+		if len(r.Errors()) != 0 {
+			printErrors(out, "resolver", r.Errors())
 			continue
 		}
+		for _, msg := range r.Warnings() {
+			io.WriteString(out, "warning: "+msg+"\n")
+		}
 		//io.WriteString(out, "--------- Parser Output ---------------------------")
 		//io.WriteString(out, program.String())
 		//io.WriteString(out, "\n")
 		//io.WriteString(out, "------------------------------------")
-		evaluated := evaluator.Eval(program, env)
+		evaluated := interpreter.Eval(program)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
@@ -62,11 +80,36 @@ func StartLang(in io.Reader, out io.Writer) {
 	}
 }
 
+//RunScript runs a whole script with the interpreter, the errors that stop it are written to errOut.
+//It reports if the script ran to the end without an error.
+func RunScript(source string, errOut io.Writer, interpreter *evaluator.Interpreter) bool {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printErrors(errOut, "parser", p.Errors())
+		return false
+	}
+	r := resolver.New(evaluator.BuiltinNames()...)
+	r.Resolve(program)
+	if len(r.Errors()) != 0 {
+		printErrors(errOut, "resolver", r.Errors())
+		return false
+	}
+	for _, msg := range r.Warnings() {
+		io.WriteString(errOut, "warning: "+msg+"\n")
+	}
+	if evaluated := interpreter.Eval(program); evaluated != nil && evaluated.Type() == object.ERROR_OBJ {
+		io.WriteString(errOut, evaluated.Inspect()+"\n")
+		return false
+	}
+	return true
+}
+
 func StartLexer(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 
 	for {
-		fmt.Printf(PROMPT)
+		fmt.Fprint(out, PROMPT)
 		scanned := scanner.Scan()
 		if !scanned {
 			return
@@ -74,11 +117,11 @@ func StartLexer(in io.Reader, out io.Writer) {
 
 		line := scanner.Text()
 		l := lexer.New(line)
-		fmt.Printf("------------- Lexer Output --------------------------------  \n")
+		fmt.Fprint(out, "------------- Lexer Output --------------------------------  \n")
 		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
-			fmt.Printf("%+v\n", tok)
+			fmt.Fprintf(out, "%+v\n", tok)
 		}
-		fmt.Printf("----------------------------------------------------------- \n")
+		fmt.Fprint(out, "----------------------------------------------------------- \n")
 	}
 }
 
@@ -86,7 +129,7 @@ func StartParser(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 
 	for {
-		fmt.Printf(PROMPT)
+		fmt.Fprint(out, PROMPT)
 		scanned := scanner.Scan()
 		if !scanned {
 			return
@@ -98,7 +141,7 @@ func StartParser(in io.Reader, out io.Writer) {
 		p := parser.New(l)
 		program := p.ParseProgram()
 		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+			printErrors(out, "parser", p.Errors())
 			continue
 		}
 		io.WriteString(out, "--------- Parser Output ------------")
@@ -110,10 +153,10 @@ func StartParser(in io.Reader, out io.Writer) {
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
+func printErrors(out io.Writer, stage string, errors []string) {
 	io.WriteString(out, ACCIDENTS)
 	io.WriteString(out, "Learning code is an art that takes years to master. Do not be disappointed if you have failed !! \n")
-	io.WriteString(out, "parser errors: \n")
+	io.WriteString(out, stage+" errors: \n")
 	for _, msg := range errors {
 		io.WriteString(out, "\t"+msg+"\n")
 	}
@@ -0,0 +1,310 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Neeraj-Natu/shifu/ast"
+)
+
+/*
+The resolver is a static pass that walks the AST produced by
+the parser before it is handed to the evaluator. It mirrors the
+scopes the evaluator creates at runtime (the program, every
+function call, every block and every loop iteration) and works
+out which declaration each name refers to. Doing this ahead of
+time means mistakes like assigning to a constant or using a name
+that was never declared are reported even when the line containing
+them would never be evaluated.
+
+Every variable that turns out to be declared in a function, block
+or loop scope gets a slot in that scope. The resolver writes the
+number of scopes to walk out (depth) and the slot index onto the
+ast.Variable, so the evaluator can find the value in an array
+instead of looking the name up in a chain of maps. Variables of
+the outermost scope stay looked up by name, that scope lives on
+between REPL lines while slots are only known for one program.
+
+Bodies of function literals are resolved when the scope they are
+written in ends, by then every name declared in that scope is
+known. A closure can therefore use a variable declared after it,
+as long as it is only called once that declaration has run.
+*/
+
+// A binding is what the resolver knows about one declared name.
+type binding struct {
+	index    int  // slot of the variable in its scope
+	constant bool // declared with const
+	used     bool // read at least once
+	report   bool // declared with let, const or func, so it is worth a warning when never used
+}
+
+// A scope holds the names declared in one function, block or loop iteration
+// and the function literals written in it that still have to be resolved.
+type scope struct {
+	bindings  map[string]*binding
+	functions []*ast.FunctionLiteral
+}
+
+func newScope() *scope {
+	return &scope{bindings: make(map[string]*binding)}
+}
+
+// Resolver keeps the outermost scope between calls to Resolve, so names declared
+// by one program are still known when the next one is resolved, just like the REPL environment.
+type Resolver struct {
+	scopes   []*scope
+	errors   []string
+	warnings []string
+}
+
+// This function creates a Resolver whose global scope already has the given names declared,
+// these are the names the evaluator provides itself such as the builtin functions.
+func New(globals ...string) *Resolver {
+	global := newScope()
+	for _, name := range globals {
+		global.bindings[name] = &binding{}
+	}
+	return &Resolver{scopes: []*scope{global}}
+}
+
+// This function returns the errors found by the last call to Resolve.
+// A program with errors is not supposed to be evaluated.
+func (r *Resolver) Errors() []string {
+	return r.errors
+}
+
+// This function returns the warnings found by the last call to Resolve, such as variables that are never used.
+func (r *Resolver) Warnings() []string {
+	return r.warnings
+}
+
+// Resolve walks the whole program. If it has errors the program is not supposed to be evaluated,
+// so any declarations it made in the global scope are forgotten again.
+func (r *Resolver) Resolve(program *ast.Program) {
+	r.errors = []string{}
+	r.warnings = []string{}
+
+	global := r.scopes[0]
+	saved := make(map[string]binding, len(global.bindings))
+	for name, b := range global.bindings {
+		saved[name] = *b
+	}
+
+	r.resolveStatements(program.Statements)
+	r.resolveFunctions(global)
+
+	if len(r.errors) != 0 {
+		global.bindings = make(map[string]*binding, len(saved))
+		for name, b := range saved {
+			b := b
+			global.bindings[name] = &b
+		}
+	}
+}
+
+func (r *Resolver) error(format string, a ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, a...))
+}
+
+func (r *Resolver) warning(format string, a ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(format, a...))
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, newScope())
+}
+
+// Before a scope goes away the functions written in it are resolved and its unused variables are reported.
+// Names starting with an underscore are meant to be unused.
+func (r *Resolver) endScope() {
+	current := r.scopes[len(r.scopes)-1]
+	r.resolveFunctions(current)
+
+	unused := []string{}
+	for name, b := range current.bindings {
+		if b.report && !b.used && name[0] != '_' {
+			unused = append(unused, name)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		return current.bindings[unused[i]].index < current.bindings[unused[j]].index
+	})
+	for _, name := range unused {
+		r.warning("unused variable: %s", name)
+	}
+
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *Resolver) resolveFunctions(s *scope) {
+	for len(s.functions) > 0 {
+		fn := s.functions[0]
+		s.functions = s.functions[1:]
+		r.resolveFunction(fn)
+	}
+}
+
+// Declares the variable in the innermost scope. Like in the evaluator a constant can be shadowed
+// by an inner scope but not declared again in its own. Declaring a name again in the same scope reuses its slot.
+func (r *Resolver) declare(v *ast.Variable, constant bool, report bool) {
+	current := r.scopes[len(r.scopes)-1]
+
+	b, ok := current.bindings[v.Value]
+	if ok && b.constant {
+		r.error("cannot redeclare constant %s", v.Value)
+		return
+	}
+	if !ok {
+		b = &binding{index: len(current.bindings)}
+		current.bindings[v.Value] = b
+	}
+	b.constant = constant
+	b.report = report
+
+	r.place(v, 0, b)
+}
+
+// Finds the innermost scope declaring the name, the same one Environment.Get would find it in,
+// and writes down on the variable where its value is going to be.
+func (r *Resolver) lookup(v *ast.Variable) (*binding, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if b, ok := r.scopes[i].bindings[v.Value]; ok {
+			r.place(v, len(r.scopes)-1-i, b)
+			return b, true
+		}
+	}
+	v.Local = false
+	return nil, false
+}
+
+func (r *Resolver) place(v *ast.Variable, depth int, b *binding) {
+	if depth == len(r.scopes)-1 {
+		v.Local = false
+		return
+	}
+	v.Local = true
+	v.Depth = depth
+	v.Index = b.index
+}
+
+// Named functions are declared before anything else in their block, the same way hoistFunctions binds them.
+func (r *Resolver) resolveStatements(statements []ast.Statement) {
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			r.declare(fs.Name, false, true)
+		}
+	}
+	for _, statement := range statements {
+		r.resolveStatement(statement)
+	}
+}
+
+func (r *Resolver) resolveBlock(block *ast.BlockStatement) {
+	if block == nil {
+		return
+	}
+	r.beginScope()
+	r.resolveStatements(block.Statements)
+	r.endScope()
+}
+
+// A function call gets one scope for both its parameters and the statements of its body.
+func (r *Resolver) resolveFunction(fn *ast.FunctionLiteral) {
+	r.beginScope()
+	for _, param := range fn.Parameters {
+		r.declare(param, false, false)
+	}
+	r.resolveStatements(fn.Body.Statements)
+	r.endScope()
+}
+
+func (r *Resolver) resolveStatement(statement ast.Statement) {
+	switch node := statement.(type) {
+	case *ast.LetStatement:
+		r.resolveExpression(node.Value)
+		r.declare(node.Name, false, true)
+	case *ast.ConstStatement:
+		r.resolveExpression(node.Value)
+		r.declare(node.Name, true, true)
+	case *ast.ReturnStatement:
+		r.resolveExpression(node.ReturnValue)
+	case *ast.ExpressionStatement:
+		r.resolveExpression(node.Expression)
+	case *ast.FunctionStatement:
+		r.resolveExpression(node.Function)
+	case *ast.AssignStatement:
+		r.resolveExpression(node.Value)
+		if variable, ok := node.Target.(*ast.Variable); ok {
+			b, ok := r.lookup(variable)
+			if !ok {
+				r.error("cannot assign to undeclared variable: %s", variable.Value)
+			} else if b.constant {
+				r.error("cannot assign to constant %s", variable.Value)
+			}
+		} else {
+			r.resolveExpression(node.Target)
+		}
+	case *ast.WhileStatement:
+		r.resolveExpression(node.Condition)
+		r.resolveBlock(node.Body)
+	case *ast.ForStatement:
+		r.resolveExpression(node.Iterable)
+		r.beginScope()
+		r.declare(node.Variable, false, false)
+		r.resolveStatements(node.Body.Statements)
+		r.endScope()
+	}
+}
+
+func (r *Resolver) resolveExpression(expression ast.Expression) {
+	switch node := expression.(type) {
+	case *ast.Variable:
+		b, ok := r.lookup(node)
+		if !ok {
+			r.error("undefined variable: %s", node.Value)
+			return
+		}
+		b.used = true
+	case *ast.PrefixExpression:
+		r.resolveExpression(node.Right)
+	case *ast.InfixExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Right)
+	case *ast.IfExpression:
+		r.resolveExpression(node.Condition)
+		r.resolveBlock(node.Consequence)
+		r.resolveBlock(node.Alternative)
+	case *ast.FunctionLiteral:
+		current := r.scopes[len(r.scopes)-1]
+		current.functions = append(current.functions, node)
+	case *ast.CallExpression:
+		r.resolveExpression(node.Function)
+		for _, arg := range node.Arguments {
+			r.resolveExpression(arg)
+		}
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			r.resolveExpression(el)
+		}
+	case *ast.SetLiteral:
+		for _, el := range node.Elements {
+			r.resolveExpression(el)
+		}
+	case *ast.IndexExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Index)
+	case *ast.SliceExpression:
+		r.resolveExpression(node.Left)
+		r.resolveExpression(node.Start)
+		r.resolveExpression(node.End)
+		r.resolveExpression(node.Step)
+	case *ast.MemberExpression:
+		r.resolveExpression(node.Object)
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			r.resolveExpression(pair.Key)
+			r.resolveExpression(pair.Value)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/Neeraj-Natu/shifu/ast"
+	"github.com/Neeraj-Natu/shifu/lexer"
+	"github.com/Neeraj-Natu/shifu/parser"
+)
+
+func TestConstants(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{"const x = 1; x;", []string{}},
+		{"const x = 1; x = 2;", []string{"cannot assign to constant x"}},
+		{"const x = 1; let x = 2;", []string{"cannot redeclare constant x"}},
+		{"const x = 1; const x = 2;", []string{"cannot redeclare constant x"}},
+		{"let x = 1; x = 2; const y = x;", []string{}},
+		{"const x = 1; if (true) { let x = 2; x = 3; }", []string{}},
+		{"const x = 1; if (true) { x = 3; }", []string{"cannot assign to constant x"}},
+		{"const x = 1; let f = func(x) { x = 2; };", []string{}},
+		{"const x = 1; let f = func() { x = 2; };", []string{"cannot assign to constant x"}},
+		{"const xs = [1]; xs[0] = 2;", []string{}},
+		{"const x = 1; for (x in [1, 2]) { x = 3; }", []string{}},
+		{"if (false) { const y = 1; y = 2; }", []string{"cannot assign to constant y"}},
+	}
+
+	for _, tt := range tests {
+		r := New()
+		r.Resolve(parse(t, tt.input))
+		checkErrors(t, tt.input, r.Errors(), tt.expectedErrors)
+	}
+}
+
+func TestGlobalScopeIsKept(t *testing.T) {
+	r := New()
+
+	r.Resolve(parse(t, "const x = 1;"))
+	checkErrors(t, "const x = 1;", r.Errors(), []string{})
+
+	r.Resolve(parse(t, "x = 2;"))
+	checkErrors(t, "x = 2;", r.Errors(), []string{"cannot assign to constant x"})
+
+	// A program with errors doesn't get evaluated, so its declarations are dropped.
+	r.Resolve(parse(t, "const y = 1; x = 3;"))
+	r.Resolve(parse(t, "let y = 2;"))
+	checkErrors(t, "let y = 2;", r.Errors(), []string{})
+}
+
+func TestUndefinedVariables(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{"x;", []string{"undefined variable: x"}},
+		{"let x = 1; x;", []string{}},
+		{"x; let x = 1;", []string{"undefined variable: x"}},
+		{"let f = func() { y };", []string{"undefined variable: y"}},
+		{"puts(1);", []string{}},
+		{"let f = func() { g() }; let g = func() { 1 };", []string{}},
+		{"let f = func() { let a = func() { b }; let b = 1; a() };", []string{}},
+		{"if (true) { let y = 1; }; y;", []string{"undefined variable: y"}},
+		{"for (x in [1]) { x; }; x;", []string{"undefined variable: x"}},
+		{"y = 1;", []string{"cannot assign to undeclared variable: y"}},
+		{"let f = func() { z = 1; };", []string{"cannot assign to undeclared variable: z"}},
+		{"if (1 > 0) { if (a) { b } }", []string{"undefined variable: a", "undefined variable: b"}},
+		{`let h = {"a": 1}; h.b; h.c = 2;`, []string{}},
+		{"x.len()", []string{"undefined variable: x"}},
+		{"#{1, a}", []string{"undefined variable: a"}},
+		{"let xs = [1]; xs[a:b:c]", []string{"undefined variable: a", "undefined variable: b", "undefined variable: c"}},
+	}
+
+	for _, tt := range tests {
+		r := New("puts")
+		r.Resolve(parse(t, tt.input))
+		checkErrors(t, tt.input, r.Errors(), tt.expectedErrors)
+	}
+}
+
+func TestUnusedVariables(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedWarnings []string
+	}{
+		{"let a = 1;", []string{}},
+		{"let f = func() { let a = 1; let b = 2; b };", []string{"unused variable: a"}},
+		{"let f = func() { let b = 1; let a = 2; 3 };", []string{"unused variable: b", "unused variable: a"}},
+		{"let f = func(x) { 1 };", []string{}},
+		{"let f = func() { let _a = 1; 2 };", []string{}},
+		{"let f = func() { func helper() { 1 } 2 };", []string{"unused variable: helper"}},
+		{"let f = func() { let a = 1; a = 2; };", []string{"unused variable: a"}},
+		{"for (x in [1]) { let y = 1; }", []string{"unused variable: y"}},
+		{"let f = func() { let a = 1; func() { a } };", []string{}},
+	}
+
+	for _, tt := range tests {
+		r := New()
+		r.Resolve(parse(t, tt.input))
+		checkErrors(t, tt.input, r.Errors(), []string{})
+		checkErrors(t, tt.input, r.Warnings(), tt.expectedWarnings)
+	}
+}
+
+func TestSlots(t *testing.T) {
+	input := `
+	let g = 1;
+	let f = func(a, b) {
+		let c = a + g;
+		if (true) { c + b }
+	};`
+
+	program := parse(t, input)
+	r := New()
+	r.Resolve(program)
+	checkErrors(t, input, r.Errors(), []string{})
+
+	testGlobal(t, program.Statements[0].(*ast.LetStatement).Name)
+
+	fn := program.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	testSlot(t, fn.Parameters[0], 0, 0)
+	testSlot(t, fn.Parameters[1], 0, 1)
+
+	let := fn.Body.Statements[0].(*ast.LetStatement)
+	testSlot(t, let.Name, 0, 2)
+	sum := let.Value.(*ast.InfixExpression)
+	testSlot(t, sum.Left.(*ast.Variable), 0, 0)
+	testGlobal(t, sum.Right.(*ast.Variable))
+
+	ifExp := fn.Body.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	inner := ifExp.Consequence.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InfixExpression)
+	testSlot(t, inner.Left.(*ast.Variable), 1, 2)
+	testSlot(t, inner.Right.(*ast.Variable), 1, 1)
+}
+
+func TestRedeclarationReusesSlot(t *testing.T) {
+	input := "let f = func() { let a = 1; let b = 2; let a = b; a };"
+
+	program := parse(t, input)
+	New().Resolve(program)
+
+	body := program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body
+	testSlot(t, body.Statements[0].(*ast.LetStatement).Name, 0, 0)
+	testSlot(t, body.Statements[1].(*ast.LetStatement).Name, 0, 1)
+	testSlot(t, body.Statements[2].(*ast.LetStatement).Name, 0, 0)
+	testSlot(t, body.Statements[3].(*ast.ExpressionStatement).Expression.(*ast.Variable), 0, 0)
+}
+
+func testSlot(t *testing.T, v *ast.Variable, depth, index int) {
+	if !v.Local {
+		t.Errorf("variable %s is not local", v.Value)
+		return
+	}
+	if v.Depth != depth || v.Index != index {
+		t.Errorf("variable %s has wrong slot. expected=(%d, %d), got=(%d, %d)", v.Value, depth, index, v.Depth, v.Index)
+	}
+}
+
+func testGlobal(t *testing.T, v *ast.Variable) {
+	if v.Local {
+		t.Errorf("variable %s is local, expected it to be global", v.Value)
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return program
+}
+
+func checkErrors(t *testing.T, input string, errors []string, expected []string) {
+	if len(errors) != len(expected) {
+		t.Errorf("wrong number of errors for %q. expected=%v, got=%v", input, expected, errors)
+		return
+	}
+	for i, msg := range expected {
+		if errors[i] != msg {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", input, msg, errors[i])
+		}
+	}
+}
@@ -14,6 +14,7 @@ const (
 	// Indentifiers and literals
 	VARIABLE = "VAR"     // add, foobar, x, y, ......
 	INT      = "INTEGER" // 123424
+	FLOAT    = "FLOAT"   // 1.5
 	STRING   = "STRING"
 
 	// Operators
@@ -25,6 +26,8 @@ const (
 	SLASH    = "/"
 	AND      = "&&"
 	OR       = "||"
+	AMP      = "&"
+	PIPE     = "|"
 	LT       = "<"
 	GT       = ">"
 
@@ -35,9 +38,11 @@ const (
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
 	LPAREN    = "("
 	RPAREN    = ")"
 	LCBRACE   = "{"
+	SET_BRACE = "#{"
 	RCBRACE   = "}"
 	LBRACE    = "["
 	RBRACE    = "]"
@@ -51,17 +56,17 @@ const (
 	ELSEIF   = "ELSEIF"
 	RETURN   = "RETURN"
 	LET      = "LET"
+	CONST    = "CONST"
 	RANGE    = "RANGE"
 	FOR      = "FOR"
 	IN       = "IN"
 	WHILE    = "WHILE"
-	LENGTH   = "LENGTH"
-	CONTAINS = "CONTAINS"
 )
 
 var keywords = map[string]TokenType{
 	"func":   FUNCTION,
 	"let":    LET,
+	"const":  CONST,
 	"true":   TRUE,
 	"false":  FALSE,
 	"if":     IF,
@@ -71,6 +76,7 @@ var keywords = map[string]TokenType{
 	"for":    FOR,
 	"while":  WHILE,
 	"range":  RANGE,
+	"in":     IN,
 }
 
 func LookupIdent(ident string) TokenType {